@@ -0,0 +1,302 @@
+package account
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/yangl1996/super-light-client/game"
+)
+
+func TestState(t *testing.T) {
+	s := NewState()
+	if s.Root() != (game.Hash{}) {
+		t.Error("empty state has a root")
+	}
+	for i := 0; i < 20; i++ {
+		s.Set(AddressOf(i), Account{Balance: uint64(i + 1)})
+	}
+	root := s.Root()
+	for i := 0; i < 25; i++ {
+		addr := AddressOf(i)
+		p := s.Prove(addr)
+		if r, ok := p.Root(addr, s.Get(addr)); !ok || r != root {
+			t.Errorf("proof of account %v does not lead to the root", i)
+		}
+		if r, _ := p.Root(addr, Account{Balance: 100}); r == root {
+			t.Errorf("proof of account %v leads to the root with another balance", i)
+		}
+	}
+
+	// the root only depends on the accounts
+	other := NewState()
+	for i := 24; i >= 0; i-- {
+		other.Set(AddressOf(i), Account{Balance: 7})
+		if i >= 20 {
+			other.Set(AddressOf(i), Account{})
+		}
+	}
+	for i := 0; i < 20; i++ {
+		other.Set(AddressOf(i), Account{Balance: uint64(i + 1)})
+	}
+	if other.Root() != root {
+		t.Error("same accounts have different roots")
+	}
+
+	p := s.Prove(AddressOf(3))
+	p.Siblings = p.Siblings[1:]
+	if _, ok := p.Root(AddressOf(3), s.Get(AddressOf(3))); ok {
+		t.Error("malformed proof is accepted")
+	}
+}
+
+func TestValidTransition(t *testing.T) {
+	s := NewState()
+	var prev []byte
+	step := func(tx Tx) ([]byte, []byte) {
+		w := s.Witness(tx).Encode()
+		if err := s.Apply(tx); err != nil {
+			t.Fatal(err)
+		}
+		return Leaf{Tx: tx, Root: s.Root()}.Encode(), w
+	}
+	txs := []Tx{
+		{To: AddressOf(0), Amount: 100},
+		{To: AddressOf(1), Amount: 50},
+		{From: AddressOf(0), To: AddressOf(1), Amount: 30},
+		{From: AddressOf(1), To: AddressOf(1), Amount: 80},
+		{From: AddressOf(1), To: AddressOf(2), Amount: 80, Nonce: 1},
+	}
+	for i, tx := range txs {
+		leaf, w := step(tx)
+		if !ValidTransition(prev, leaf, w) {
+			t.Fatalf("valid transition %v is rejected", i)
+		}
+		if ValidTransition(prev, leaf, nil) {
+			t.Errorf("transition %v is accepted without a witness", i)
+		}
+		prev = leaf
+	}
+
+	before := s.Root()
+	tx := Tx{From: AddressOf(2), To: AddressOf(0), Amount: 10}
+	w := s.Witness(tx).Encode()
+	s.Apply(tx)
+	good := Leaf{Tx: tx, Root: s.Root()}
+	if !ValidTransition(prev, good.Encode(), w) {
+		t.Fatal("valid transition is rejected")
+	}
+	bad := good
+	bad.Tx.Amount = 11
+	if ValidTransition(prev, bad.Encode(), w) {
+		t.Error("transition to the wrong root is accepted")
+	}
+	bad = good
+	bad.Tx.Nonce = 1
+	if ValidTransition(prev, bad.Encode(), w) {
+		t.Error("transition with the wrong nonce is accepted")
+	}
+	bad = good
+	bad.Tx.Amount = 1000
+	bad.Root = before
+	if ValidTransition(prev, bad.Encode(), w) {
+		t.Error("overdraft is accepted")
+	}
+	if ValidTransition(good.Encode(), good.Encode(), w) {
+		t.Error("witness against another state is accepted")
+	}
+	tx = Tx{To: AddressOf(0), Amount: 10}
+	leaf, w := step(tx)
+	if ValidTransition(good.Encode(), leaf, w) {
+		t.Error("mint after a transfer is accepted")
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	// openings in any order, and at the same time, get the witnesses from the
+	// checkpoints that the others leave
+	const size = 5*checkpointInterval + 10
+	tree := NewTree(game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), NewGenerator(10, 1, -1), size, 3))
+	wg := &sync.WaitGroup{}
+	for _, idx := range []int{size - 1, 3*checkpointInterval + 7, checkpointInterval, 2 * checkpointInterval, 17, size - 1, 4*checkpointInterval - 1} {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if !ValidTransition(tree.GetData(idx-1), tree.GetData(idx), tree.GetWitness(idx)) {
+				t.Errorf("generated transition %v is rejected", idx)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	if len(tree.checkpoints) != 6 {
+		t.Errorf("tree keeps %v checkpoints instead of 6", len(tree.checkpoints))
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	gen := NewGenerator(10, 1, -1)
+	var prev []byte
+	tree := NewTree(game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), gen, 200, 3))
+	for _, i := range []int{0, 5, 150, 199, 40, 41} {
+		if i > 0 {
+			prev = tree.GetData(i - 1)
+		} else {
+			prev = nil
+		}
+		if !ValidTransition(prev, tree.GetData(i), tree.GetWitness(i)) {
+			t.Errorf("generated transition %v is rejected", i)
+		}
+	}
+}
+
+const dim = 3
+
+// play runs a verifier over ledgers that the generator makes with the given
+// points of difference, and returns the index of the winner and the reasons of
+// the matches.
+func play(size int, diffs ...int) (int, []game.Reason) {
+	var trees []game.MerkleTree
+	for _, diff := range diffs {
+		trees = append(trees, NewTree(game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), NewGenerator(10, 1, diff), size, dim)))
+	}
+	return playTrees(trees...)
+}
+
+// playTrees is play over the trees of ledgers.
+func playTrees(trees ...game.MerkleTree) (int, []game.Reason) {
+	v := &game.Verifier{
+		Dim:             dim,
+		MerkleHasher:    game.NewSHA256Hasher(dim),
+		ValidTransition: ValidTransition,
+	}
+	wg := &sync.WaitGroup{}
+	var toSessions []chan game.Message
+	for _, tree := range trees {
+		i := make(chan game.Message, 100)
+		o := make(chan game.Message, 100)
+		s := &game.Session{Tree: tree, I: i, O: o}
+		wg.Add(1)
+		go func() {
+			s.Run()
+			wg.Done()
+		}()
+		v.To = append(v.To, i)
+		v.From = append(v.From, o)
+		toSessions = append(toSessions, i)
+	}
+	_, winner, stats := v.Run()
+	for _, ch := range toSessions {
+		close(ch)
+	}
+	wg.Wait()
+	var reasons []game.Reason
+	for _, s := range stats {
+		reasons = append(reasons, s.Reason)
+	}
+	return winner, reasons
+}
+
+func TestGame(t *testing.T) {
+	for _, diff := range []int{0, 5, 60, 99} {
+		if winner, reasons := play(100, diff, -1); winner != 1 || len(reasons) != 1 || reasons[0] != game.ReasonInvalidTransition {
+			t.Errorf("ledger invalid at %v is not rejected: winner %v, %v", diff, winner, reasons)
+		}
+		if winner, reasons := play(100, -1, diff); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonValidTransition {
+			t.Errorf("ledger invalid at %v wins against the valid one: winner %v, %v", diff, winner, reasons)
+		}
+	}
+}
+
+func TestLateMint(t *testing.T) {
+	// the liar forks off at k with mints to itself, which are valid transitions
+	// of the state but come after the genesis
+	const size, k = 100, 50
+	gen := NewGenerator(10, 1, -1)
+	state := NewState()
+	var leaves, liar [][]byte
+	for i := 0; i < size; i++ {
+		leaves = append(leaves, gen(i))
+		if i < k {
+			dl, _ := DecodeLeaf(leaves[i])
+			state.Apply(dl.Tx)
+			liar = append(liar, leaves[i])
+		}
+	}
+	for len(liar) < size-20 {
+		tx := Tx{To: AddressOf(0), Amount: mintAmount}
+		state.Apply(tx)
+		liar = append(liar, Leaf{Tx: tx, Root: state.Root()}.Encode())
+	}
+	newTree := func(leaves [][]byte) *Tree {
+		data := func(i int) []byte {
+			return leaves[i]
+		}
+		return NewTree(game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), data, len(leaves), dim))
+	}
+	if winner, reasons := playTrees(newTree(leaves), newTree(liar)); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonInvalidTransition {
+		t.Errorf("ledger that mints after the genesis is not rejected: winner %v, %v", winner, reasons)
+	}
+	if winner, reasons := playTrees(newTree(liar), newTree(leaves)); winner != 1 || len(reasons) != 1 || reasons[0] != game.ReasonInvalidTransition {
+		t.Errorf("ledger that mints after the genesis is not rejected: winner %v, %v", winner, reasons)
+	}
+}
+
+// stale is a tree that opens the leaf at from in place of the one at idx, with the
+// witness of the transition from it into the leaf after idx.
+type stale struct {
+	*Tree
+	idx, from int
+	witness   []byte
+}
+
+func (s stale) GetData(idx int) []byte {
+	if idx == s.idx {
+		idx = s.from
+	}
+	return s.Tree.GetData(idx)
+}
+
+func (s stale) GetProof(idx int) []game.Hash {
+	if idx == s.idx {
+		idx = s.from
+	}
+	return s.Tree.GetProof(idx)
+}
+
+func (s stale) GetWitness(idx int) []byte {
+	if idx == s.idx+1 {
+		return s.witness
+	}
+	return s.Tree.GetWitness(idx)
+}
+
+func TestStalePreState(t *testing.T) {
+	const size, k = 100, 50
+	gen := NewGenerator(10, 1, -1)
+	var leaves [][]byte
+	for i := 0; i < size; i++ {
+		leaves = append(leaves, gen(i))
+	}
+	newTree := func(leaves [][]byte) *Tree {
+		data := func(i int) []byte {
+			return leaves[i]
+		}
+		return NewTree(game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), data, len(leaves), dim))
+	}
+
+	// the liar repeats the transfer at k-1 at k, which spends again from the state
+	// before k-1, and opens the leaf at k-2 as the one before it
+	state := NewState()
+	for _, l := range leaves[:k-1] {
+		dl, _ := DecodeLeaf(l)
+		state.Apply(dl.Tx)
+	}
+	repeated, _ := DecodeLeaf(leaves[k-1])
+	witness := state.Witness(repeated.Tx).Encode()
+	if !ValidTransition(leaves[k-2], leaves[k-1], witness) {
+		t.Fatal("transfer is rejected from the state before it")
+	}
+	liar := append(append([][]byte{}, leaves[:k]...), leaves[k-1])
+	if winner, reasons := playTrees(newTree(leaves), stale{newTree(liar), k - 1, k - 2, witness}); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonPrevLeafProof {
+		t.Errorf("ledger that opens a stale state is not rejected: winner %v, %v", winner, reasons)
+	}
+}
@@ -0,0 +1,315 @@
+package account
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"sync"
+
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// Tx is a transfer of Amount coins from one account to another. A transfer from
+// the zero address mints the coins instead, which a ledger may only do in its
+// genesis: the leaves before its first transfer.
+type Tx struct {
+	From   Address
+	To     Address
+	Amount uint64
+	Nonce  uint64 // the nonce of the sender before the transfer
+}
+
+func (tx Tx) mint() bool {
+	return tx.From == Address{}
+}
+
+var (
+	ErrNonce    = errors.New("wrong nonce")
+	ErrBalance  = errors.New("insufficient balance")
+	ErrOverflow = errors.New("balance overflows")
+	ErrSize     = errors.New("leaf is not 112 bytes")
+)
+
+// debit returns the sender after it sends the transaction.
+func debit(a Account, tx Tx) (Account, error) {
+	if a.Nonce != tx.Nonce {
+		return a, ErrNonce
+	}
+	if a.Balance < tx.Amount {
+		return a, ErrBalance
+	}
+	a.Nonce++
+	a.Balance -= tx.Amount
+	return a, nil
+}
+
+// credit returns the receiver after it receives the transaction.
+func credit(a Account, tx Tx) (Account, error) {
+	if a.Balance+tx.Amount < a.Balance {
+		return a, ErrOverflow
+	}
+	a.Balance += tx.Amount
+	return a, nil
+}
+
+// execute returns the sender and the receiver after the transaction, or an error
+// if it fails. The sender is empty for a mint.
+func (s *State) execute(tx Tx) (from, to Account, err error) {
+	if !tx.mint() {
+		if from, err = debit(s.Get(tx.From), tx); err != nil {
+			return
+		}
+	}
+	to = s.Get(tx.To)
+	if !tx.mint() && tx.To == tx.From {
+		to = from
+	}
+	to, err = credit(to, tx)
+	return
+}
+
+// Apply executes the transaction on the state. The state does not change if the
+// transaction fails.
+func (s *State) Apply(tx Tx) error {
+	from, to, err := s.execute(tx)
+	if err != nil {
+		return err
+	}
+	if !tx.mint() {
+		s.Set(tx.From, from)
+	}
+	s.Set(tx.To, to)
+	return nil
+}
+
+// LeafSize is the number of bytes of an encoded leaf.
+const LeafSize = 32 + 32 + 8 + 8 + 32
+
+// Leaf is a leaf of the ledger: a transaction and the root of the state after it.
+type Leaf struct {
+	Tx   Tx
+	Root game.Hash
+}
+
+func (l Leaf) Encode() []byte {
+	b := make([]byte, LeafSize)
+	copy(b[0:32], l.Tx.From[:])
+	copy(b[32:64], l.Tx.To[:])
+	binary.LittleEndian.PutUint64(b[64:72], l.Tx.Amount)
+	binary.LittleEndian.PutUint64(b[72:80], l.Tx.Nonce)
+	copy(b[80:112], l.Root[:])
+	return b
+}
+
+func DecodeLeaf(b []byte) (Leaf, error) {
+	var l Leaf
+	if len(b) != LeafSize {
+		return l, ErrSize
+	}
+	copy(l.Tx.From[:], b[0:32])
+	copy(l.Tx.To[:], b[32:64])
+	l.Tx.Amount = binary.LittleEndian.Uint64(b[64:72])
+	l.Tx.Nonce = binary.LittleEndian.Uint64(b[72:80])
+	copy(l.Root[:], b[80:112])
+	return l, nil
+}
+
+// Witness is what the verifier needs to execute a transaction: the sender with
+// its proof against the state before the transaction, and the receiver with its
+// proof against the state after the sender pays. The sender is empty for a mint.
+type Witness struct {
+	From      Account
+	FromProof Proof
+	To        Account
+	ToProof   Proof
+}
+
+func (w Witness) Encode() []byte {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(w); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func DecodeWitness(b []byte) (Witness, error) {
+	var w Witness
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&w)
+	return w, err
+}
+
+// Witness returns the witness of the transaction against the state, which it
+// must be possible to apply.
+func (s *State) Witness(tx Tx) Witness {
+	var w Witness
+	if !tx.mint() {
+		w.From = s.Get(tx.From)
+		w.FromProof = s.Prove(tx.From)
+		from, _, err := s.execute(tx)
+		if err != nil {
+			panic(err)
+		}
+		s.Set(tx.From, from)
+		defer s.Set(tx.From, w.From)
+	}
+	w.To = s.Get(tx.To)
+	w.ToProof = s.Prove(tx.To)
+	return w
+}
+
+// ValidTransition tells if the leaf to follows the leaf from: executing its
+// transaction on the accounts in the witness takes the root of from to the root of
+// to. From is nil if to is the first leaf, which starts from the empty state. A
+// mint only follows another mint, or the empty state, so that a fork cannot make
+// up coins after the genesis. It can be used as game.Verifier.ValidTransition,
+// which checks that from is the leaf right before to; any earlier root would let
+// a transfer spend from a stale state.
+func ValidTransition(from, to, witness []byte) bool {
+	next, err := DecodeLeaf(to)
+	if err != nil {
+		return false
+	}
+	var root game.Hash
+	genesis := true
+	if from != nil {
+		prev, err := DecodeLeaf(from)
+		if err != nil {
+			return false
+		}
+		root = prev.Root
+		genesis = prev.Tx.mint()
+	}
+	if next.Tx.mint() && !genesis {
+		return false
+	}
+	w, err := DecodeWitness(witness)
+	if err != nil {
+		return false
+	}
+	tx := next.Tx
+	if !tx.mint() {
+		if r, ok := w.FromProof.Root(tx.From, w.From); !ok || r != root {
+			return false
+		}
+		a, err := debit(w.From, tx)
+		if err != nil {
+			return false
+		}
+		root, _ = w.FromProof.Root(tx.From, a)
+	}
+	if r, ok := w.ToProof.Root(tx.To, w.To); !ok || r != root {
+		return false
+	}
+	a, err := credit(w.To, tx)
+	if err != nil {
+		return false
+	}
+	root, _ = w.ToProof.Root(tx.To, a)
+	return root == next.Root
+}
+
+// mintAmount is the number of coins that a generated ledger mints to each account.
+const mintAmount = 1000000
+
+// NewGenerator returns a generator of a ledger that mints coins to the given
+// number of accounts, and then makes random transfers between them, picked by
+// seed. The leaf at index diff, unless it is negative, credits the receiver one
+// coin more than the sender pays, so the ledger is invalid from there. The leaves
+// must be generated in order.
+func NewGenerator(accounts int, seed int64, diff int) game.MerkleTreeDataGenerator {
+	state := NewState()
+	rng := rand.New(rand.NewSource(seed))
+	next := 0
+	return func(i int) []byte {
+		if i != next {
+			panic("leaves of the ledger are not generated in order")
+		}
+		next++
+		var tx Tx
+		if i < accounts {
+			tx = Tx{To: AddressOf(i), Amount: mintAmount}
+		} else {
+			tx.From = AddressOf(rng.Intn(accounts))
+			tx.To = AddressOf(rng.Intn(accounts))
+			from := state.Get(tx.From)
+			tx.Nonce = from.Nonce
+			tx.Amount = uint64(rng.Int63n(int64(from.Balance/2 + 1)))
+		}
+		if err := state.Apply(tx); err != nil {
+			panic(err)
+		}
+		if i == diff {
+			to := state.Get(tx.To)
+			to.Balance++
+			state.Set(tx.To, to)
+		}
+		return Leaf{Tx: tx, Root: state.Root()}.Encode()
+	}
+}
+
+// checkpointInterval is the number of leaves between the states that Tree keeps.
+const checkpointInterval = 256
+
+// Tree is the tree of a ledger of accounts, which sends the witness of the
+// transition into each leaf that it opens. It replays the transactions of the
+// ledger to get the state before the leaf, from the last checkpoint before it,
+// and keeps the accounts before every checkpointInterval leaves as it replays past
+// them, so that an opening replays less than checkpointInterval transactions once
+// the ledger has been replayed up to it. Transactions that fail leave the state as
+// it is, so the witnesses are only right up to the first invalid transition. It is
+// safe for concurrent use.
+type Tree struct {
+	game.MerkleTree
+	lock        sync.Mutex
+	checkpoints []map[Address]Account // before leaf i*checkpointInterval
+}
+
+func NewTree(t game.MerkleTree) *Tree {
+	return &Tree{MerkleTree: t, checkpoints: []map[Address]Account{{}}}
+}
+
+// checkpoint returns the last checkpoint at or before c that the tree has, and
+// its index.
+func (t *Tree) checkpoint(c int) (map[Address]Account, int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if c >= len(t.checkpoints) {
+		c = len(t.checkpoints) - 1
+	}
+	return t.checkpoints[c], c
+}
+
+// record keeps the accounts as checkpoint c, if it is the next one.
+func (t *Tree) record(c int, accounts map[Address]Account) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if c == len(t.checkpoints) {
+		t.checkpoints = append(t.checkpoints, accounts)
+	}
+}
+
+func (t *Tree) GetWitness(idx int) []byte {
+	accounts, c := t.checkpoint(idx / checkpointInterval)
+	state := NewState()
+	for addr, a := range accounts {
+		state.Set(addr, a)
+	}
+	for i := c * checkpointInterval; i < idx; i++ {
+		if i%checkpointInterval == 0 && i > c*checkpointInterval {
+			t.record(i/checkpointInterval, state.copyAccounts())
+		}
+		if l, err := DecodeLeaf(t.GetData(i)); err == nil {
+			state.Apply(l.Tx)
+		}
+	}
+	l, err := DecodeLeaf(t.GetData(idx))
+	if err != nil {
+		return nil
+	}
+	if _, _, err := state.execute(l.Tx); err != nil {
+		return nil
+	}
+	return state.Witness(l.Tx).Encode()
+}
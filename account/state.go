@@ -0,0 +1,198 @@
+// Package account is a reference ledger of transfers between accounts, on which
+// the verifier checks the transitions without holding the state. Every leaf is a
+// transaction together with the root of the state after it, and the state is a
+// sparse Merkle tree of the accounts. The prover sends the accounts that the
+// transaction touches, with their proofs, as the witness of the transition, and
+// the verifier executes the transaction on them to get from the root of the leaf
+// before to the root of the leaf.
+package account
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// Address is the key of an account in the state.
+type Address [32]byte
+
+// depth is the number of levels of the state tree below its root, one per bit of
+// an address.
+const depth = 256
+
+// AddressOf returns the address of the i-th account of a generated ledger.
+func AddressOf(i int) Address {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(i))
+	return sha256.Sum256(b[:])
+}
+
+// bit returns the bit of the address that picks the child at depth d.
+func (a Address) bit(d int) int {
+	return int(a[d/8]>>(7-d%8)) & 1
+}
+
+// prefix returns the address with the bits from d on cleared, which identifies
+// the node at depth d over it.
+func (a Address) prefix(d int) Address {
+	var p Address
+	copy(p[:d/8], a[:d/8])
+	if d%8 != 0 {
+		p[d/8] = a[d/8] & ^byte(0xff>>(d%8))
+	}
+	return p
+}
+
+// flip returns the address with the bit at depth d flipped.
+func (a Address) flip(d int) Address {
+	a[d/8] ^= 1 << (7 - d%8)
+	return a
+}
+
+type Account struct {
+	Balance uint64
+	Nonce   uint64 // number of transfers from the account
+}
+
+func (a Account) empty() bool {
+	return a == Account{}
+}
+
+// leafHash is the hash of an account in the state tree. Empty accounts are empty
+// subtrees, so the state tree only holds the accounts that have been used.
+func leafHash(addr Address, a Account) game.Hash {
+	if a.empty() {
+		return game.Hash{}
+	}
+	var b [1 + 32 + 16]byte
+	copy(b[1:33], addr[:])
+	binary.LittleEndian.PutUint64(b[33:41], a.Balance)
+	binary.LittleEndian.PutUint64(b[41:49], a.Nonce)
+	return sha256.Sum256(b[:])
+}
+
+func innerHash(left, right game.Hash) game.Hash {
+	if left == (game.Hash{}) && right == (game.Hash{}) {
+		return game.Hash{}
+	}
+	var b [1 + 64]byte
+	b[0] = 1
+	copy(b[1:33], left[:])
+	copy(b[33:65], right[:])
+	return sha256.Sum256(b[:])
+}
+
+type nodeKey struct {
+	depth  int
+	prefix Address
+}
+
+// State is a sparse Merkle tree of accounts. It keeps the accounts, and the nodes
+// of the tree that are not empty.
+type State struct {
+	accounts map[Address]Account
+	nodes    map[nodeKey]game.Hash
+}
+
+func NewState() *State {
+	return &State{
+		accounts: make(map[Address]Account),
+		nodes:    make(map[nodeKey]game.Hash),
+	}
+}
+
+// Root returns the root of the state tree, which is empty for the empty state.
+func (s *State) Root() game.Hash {
+	return s.nodes[nodeKey{0, Address{}}]
+}
+
+func (s *State) Get(addr Address) Account {
+	return s.accounts[addr]
+}
+
+func (s *State) copyAccounts() map[Address]Account {
+	res := make(map[Address]Account, len(s.accounts))
+	for addr, a := range s.accounts {
+		res[addr] = a
+	}
+	return res
+}
+
+func (s *State) node(d int, prefix Address) game.Hash {
+	return s.nodes[nodeKey{d, prefix}]
+}
+
+func (s *State) setNode(d int, prefix Address, h game.Hash) {
+	if h == (game.Hash{}) {
+		delete(s.nodes, nodeKey{d, prefix})
+	} else {
+		s.nodes[nodeKey{d, prefix}] = h
+	}
+}
+
+// Set updates the account, and the nodes on its path to the root.
+func (s *State) Set(addr Address, a Account) {
+	if a.empty() {
+		delete(s.accounts, addr)
+	} else {
+		s.accounts[addr] = a
+	}
+	h := leafHash(addr, a)
+	s.setNode(depth, addr, h)
+	for d := depth - 1; d >= 0; d-- {
+		sibling := s.node(d+1, addr.flip(d).prefix(d+1))
+		if addr.bit(d) == 0 {
+			h = innerHash(h, sibling)
+		} else {
+			h = innerHash(sibling, h)
+		}
+		s.setNode(d, addr.prefix(d), h)
+	}
+}
+
+// Proof is the path of an account in the state tree: the siblings of the nodes
+// from the account up to the root. Most of them are empty, so it keeps the ones
+// that are not, and marks them in Bitmap, where bit i is the sibling i levels
+// above the account.
+type Proof struct {
+	Bitmap   [depth / 8]byte
+	Siblings []game.Hash
+}
+
+// Prove returns the proof of the account at the address, which may be empty.
+func (s *State) Prove(addr Address) Proof {
+	var p Proof
+	for d := depth - 1; d >= 0; d-- {
+		sibling := s.node(d+1, addr.flip(d).prefix(d+1))
+		if sibling != (game.Hash{}) {
+			i := depth - 1 - d
+			p.Bitmap[i/8] |= 1 << (i % 8)
+			p.Siblings = append(p.Siblings, sibling)
+		}
+	}
+	return p
+}
+
+// Root returns the root of the state tree in which the account at the address is
+// a, and the rest are as in the proof. It returns false if the proof is malformed.
+func (p Proof) Root(addr Address, a Account) (game.Hash, bool) {
+	h := leafHash(addr, a)
+	next := 0
+	for d := depth - 1; d >= 0; d-- {
+		var sibling game.Hash
+		if i := depth - 1 - d; p.Bitmap[i/8]&(1<<(i%8)) != 0 {
+			if next >= len(p.Siblings) {
+				return game.Hash{}, false
+			}
+			sibling = p.Siblings[next]
+			next++
+		}
+		if addr.bit(d) == 0 {
+			h = innerHash(h, sibling)
+		} else {
+			h = innerHash(sibling, h)
+		}
+	}
+	return h, next == len(p.Siblings)
+}
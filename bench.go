@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// parseDurations parses a comma-separated list of durations.
+func parseDurations(s string) []time.Duration {
+	var res []time.Duration
+	for _, f := range strings.Split(s, ",") {
+		if f == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// parseInts parses a comma-separated list of integers.
+func parseInts(s string) []int {
+	var res []int
+	for _, f := range strings.Split(s, ",") {
+		if f == "" {
+			continue
+		}
+		i, err := strconv.Atoi(f)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
+// benchTrees builds the honest ledger of the given size, and one fork of the same
+// size for each divergence point.
+func benchTrees(size, dim int, diffs []int) []game.MerkleTree {
+	data := func(diff int) game.MerkleTreeDataGenerator {
+		return func(i int) []byte {
+			bs := make([]byte, 8)
+			binary.LittleEndian.PutUint64(bs, uint64(i))
+			if diff == i {
+				bs = append(bs, []byte("diff")...)
+			}
+			return bs
+		}
+	}
+	trees := []game.MerkleTree{game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), data(-1), size, dim)}
+	for _, d := range diffs {
+		if d >= size {
+			log.Fatalf("divergence point %v is not in a ledger of %v elements\n", d, size)
+		}
+		trees = append(trees, game.NewKVMerkleTree(game.NewInMemoryMerkleTreeStorage(), data(d), size, dim))
+	}
+	return trees
+}
+
+func bench(args []string) {
+	cmd := flag.NewFlagSet("bench", flag.ExitOnError)
+	sizeList := cmd.String("size", "100000", "comma-separated ledger sizes to sweep")
+	dimList := cmd.String("dim", "50", "comma-separated degrees of the tree to sweep")
+	burstList := cmd.String("p", "1", "comma-separated numbers of threads generating verifications to sweep")
+	diffList := cmd.String("diffs", "1000", "comma-separated divergence points, one server with a forked ledger for each")
+	num := cmd.Int("N", 10, "number of back-to-back verifications per thread")
+	latencyList := cmd.String("latency", "0s", "comma-separated one-way latencies of the links to the servers, the last one repeated for the rest")
+	jitter := cmd.Duration("jitter", 0, "random extra latency of every message, up to this much")
+	bandwidth := cmd.Int("bandwidth", 0, "bandwidth of every link in bytes per second, 0 for unlimited")
+	loss := cmd.Float64("loss", 0, "probability that a message is dropped")
+	timeout := cmd.Duration("timeout", 0, "time to wait for a server before it loses, 0 to wait forever")
+	depth := cmd.Int("depth", 1, "number of tree levels the prover opens in every round of the game")
+	stateless := cmd.Bool("stateless", false, "name the node to open in every request to the provers")
+	parallel := cmd.Bool("parallel", false, "run matches between disjoint pairs of servers in parallel")
+	cmd.Parse(args)
+
+	sizes, dims, bursts := parseInts(*sizeList), parseInts(*dimList), parseInts(*burstList)
+	diffs := parseInts(*diffList)
+	latencies := parseDurations(*latencyList)
+	if len(latencies) == 0 {
+		latencies = []time.Duration{0}
+	}
+	if *loss > 0 && *timeout == 0 {
+		log.Fatalln("set a timeout for the verifier to get past dropped messages")
+	}
+	if len(sizes) == 0 || len(dims) == 0 || len(bursts) == 0 {
+		log.Fatalln("size, dim and p must not be empty")
+	}
+	if len(diffs) == 0 {
+		log.Fatalln("supply at least one divergence point")
+	}
+	// at most one of the parameters is swept, and it goes to the first column
+	name, values := "ntx", sizes
+	swept := 0
+	for _, p := range []struct {
+		name   string
+		values []int
+	}{{"ntx", sizes}, {"degree", dims}, {"nthreads", bursts}} {
+		if len(p.values) > 1 {
+			name, values = p.name, p.values
+			swept += 1
+		}
+	}
+	if swept > 1 {
+		log.Fatalln("sweep at most one of size, dim and p")
+	}
+
+	fmt.Printf("# Setup: %v servers in process, links with %v latency, %v jitter, %v B/s bandwidth (0 for unlimited) and %v loss. Vary %v and record the avg/stddev over %v runs per thread\n", len(diffs)+1, *latencyList, *jitter, *bandwidth, *loss, name, *num)
+	fmt.Printf("# %v     avg time (ms)    stddev (ms)    avg bytes\n", name)
+	var trees []game.MerkleTree
+	builtSize, builtDim := -1, -1
+	for i := range values {
+		size, dim, burst := sizes[0], dims[0], bursts[0]
+		switch name {
+		case "ntx":
+			size = values[i]
+		case "degree":
+			dim = values[i]
+		case "nthreads":
+			burst = values[i]
+		}
+		if size != builtSize || dim != builtDim {
+			log.Printf("building %v trees of %v elements with degree %v\n", len(diffs)+1, size, dim)
+			trees = benchTrees(size, dim, diffs)
+			builtSize, builtDim = size, dim
+		}
+
+		var links []*game.Link
+		durations := make(chan float64, burst**num)
+		wg := &sync.WaitGroup{}
+		for t := 0; t < burst; t++ {
+			v := &game.Verifier{
+				Dim:          dim,
+				MerkleHasher: game.NewSHA256Hasher(dim),
+				Stateless:    *stateless,
+				Parallel:     *parallel,
+				Depth:        *depth,
+				Timeout:      *timeout,
+			}
+			for k, tree := range trees {
+				latency := latencies[len(latencies)-1]
+				if k < len(latencies) {
+					latency = latencies[k]
+				}
+				up := &game.Link{Delay: latency, Jitter: *jitter, Bandwidth: *bandwidth, Loss: *loss}
+				down := &game.Link{Delay: latency, Jitter: *jitter, Bandwidth: *bandwidth, Loss: *loss}
+				links = append(links, up, down)
+				to, from := game.RunSession(tree, up, down)
+				v.To = append(v.To, to)
+				v.From = append(v.From, from)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < *num; j++ {
+					start := time.Now()
+					v.Run()
+					durations <- float64(time.Since(start).Microseconds()) / 1000.0
+				}
+				for _, ch := range v.To {
+					close(ch)
+				}
+			}()
+		}
+		wg.Wait()
+		close(durations)
+
+		tot, totSq, cnt := 0.0, 0.0, 0
+		for d := range durations {
+			tot += d
+			totSq += d * d
+			cnt += 1
+		}
+		var transferred int64
+		for _, l := range links {
+			_, n := l.Sent()
+			transferred += n
+		}
+		avg := tot / float64(cnt)
+		stddev := math.Sqrt(totSq/float64(cnt) - avg*avg)
+		fmt.Printf("%v %.2f %.2f %v\n", values[i], avg, stddev, transferred/int64(cnt))
+	}
+}
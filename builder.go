@@ -2,17 +2,102 @@ package main
 
 import (
 	"flag"
+	"github.com/yangl1996/super-light-client/account"
 	"github.com/yangl1996/super-light-client/game"
+	"github.com/yangl1996/super-light-client/header"
 	"log"
+	"os"
 	"encoding/binary"
 )
 
+// openStorage opens the tree at path with the given storage backend.
+func openStorage(backend string, path string) game.DiskBackedMerkleTreeStorage {
+	switch backend {
+	case "pogreb":
+		return game.NewPogrebMerkleTreeStorage(path)
+	case "flat":
+		return game.NewFlatFileMerkleTreeStorage(path)
+	default:
+		log.Fatalln("unknown storage backend", backend)
+		return nil
+	}
+}
+
+// testWeight is the weight of a leaf of the test ledger in a weighted tree: its
+// length in bytes, so that the leaf where a fork starts weighs more.
+func testWeight(data []byte) uint64 {
+	return uint64(len(data))
+}
+
+// newHasher returns the hasher of the trees of a ledger, which commits to the
+// weights of the leaves of the weighted and headers ledgers.
+func newHasher(dim int, ledger string) game.MerkleHasher {
+	switch ledger {
+	case "test":
+		return game.NewSHA256Hasher(dim)
+	case "weighted":
+		return game.NewWeightedHasher(dim, testWeight)
+	case "headers":
+		return game.NewWeightedHasher(dim, header.Weight)
+	case "accounts":
+		return game.NewSHA256Hasher(dim)
+	default:
+		log.Fatalln("unknown ledger", ledger)
+		return nil
+	}
+}
+
+// validTransition returns the check of the transitions of a ledger, which is nil
+// for the test ledgers.
+func validTransition(ledger string) func(from, to, witness []byte) bool {
+	switch ledger {
+	case "headers":
+		return header.ValidTransition
+	case "accounts":
+		return account.ValidTransition
+	}
+	return nil
+}
+
+// witnessTree wraps the tree of a ledger that sends witnesses with its leaves.
+func witnessTree(tree game.MerkleTree, ledger string) game.MerkleTree {
+	if ledger == "accounts" {
+		return account.NewTree(tree)
+	}
+	return tree
+}
+
+// readHeaders reads a file of block headers, and warns if they do not make a
+// valid chain, which the verifier would reject.
+func readHeaders(path string) [][]byte {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	headers, err := header.ReadAll(f)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if i := header.FirstInvalid(headers); i != -1 {
+		log.Printf("warning: header %v is not a valid transition from the one before it\n", i)
+	}
+	log.Printf("read %v headers\n", len(headers))
+	return headers
+}
+
 func buildTree(args []string) {
 	cmd := flag.NewFlagSet("build", flag.ExitOnError)
 	size := cmd.Int("size", 1000000, "number of elements to insert")
-	path := cmd.String("file", "tree.pogreb", "file to store the dirty tree")
+	path := cmd.String("file", "tree.pogreb", "file to store the dirty tree, or directory for the flat storage")
+	backend := cmd.String("storage", "pogreb", "storage backend, pogreb or flat")
 	dim := cmd.Int("dim", 50, "degree/dimension of the tree")
 	diff := cmd.Int("diff", 0, "point of difference")
+	single := cmd.Bool("single", false, "commit to the ledger with a single, possibly partial, root")
+	inmem := cmd.Bool("inmem", false, "build the tree in memory and then write it to the disk")
+	ledger := cmd.String("ledger", "test", "ledger in the trees: test, weighted for the test ledger with weights, headers for block headers, or accounts for transfers between accounts")
+	headersPath := cmd.String("headers", "", "file of block headers to build the tree over for the headers ledger, one 80-byte header after another")
+	accounts := cmd.Int("accounts", 100, "number of accounts in the accounts ledger")
 	cmd.Parse(args)
 
 	testData := func(i int) []byte {
@@ -24,8 +109,50 @@ func buildTree(args []string) {
 		return bs
 	}
 
-	storage := game.NewPogrebMerkleTreeStorage(*path)
-	game.NewKVMerkleTree(storage, testData, *size, *dim)
+	storage := openStorage(*backend, *path)
+	var building game.KVMerkleTreeStorage = storage
+	if *inmem {
+		building = game.NewInMemoryMerkleTreeStorage()
+	}
+	if *ledger == "headers" {
+		headers := readHeaders(*headersPath)
+		*size = len(headers)
+		testData = func(i int) []byte {
+			return headers[i]
+		}
+	}
+	if *ledger == "accounts" {
+		accountDiff := -1
+		if *diff != 0 {
+			accountDiff = *diff
+		}
+		testData = account.NewGenerator(*accounts, 1, accountDiff)
+	}
+	tree := game.NewKVMerkleTreeWithHasher(building, testData, *size, *dim, newHasher(*dim, *ledger), *single)
+	if *inmem {
+		log.Println("writing the tree to the disk")
+		tree.CopyTo(storage)
+	}
+	log.Println("committing to the disk")
+	storage.Commit()
+	storage.Close()
+}
+
+func truncateTree(args []string) {
+	cmd := flag.NewFlagSet("truncate", flag.ExitOnError)
+	path := cmd.String("file", "tree.pogreb", "file of the tree, or directory for the flat storage")
+	backend := cmd.String("storage", "pogreb", "storage backend, pogreb or flat")
+	size := cmd.Int("size", 0, "number of leaves to keep")
+	ledger := cmd.String("ledger", "test", "ledger in the trees: test, weighted for the test ledger with weights, headers for block headers, or accounts for transfers between accounts")
+	cmd.Parse(args)
+
+	storage := openStorage(*backend, *path)
+	tree := game.OpenKVMerkleTreeWithHasher(storage, newHasher(storage.GetDegree(), *ledger))
+	if *size > tree.NumLeaves() {
+		log.Fatalf("tree has only %v leaves\n", tree.NumLeaves())
+	}
+	log.Printf("truncating the tree from %v to %v leaves\n", tree.NumLeaves(), *size)
+	tree.Truncate(*size)
 	log.Println("committing to the disk")
 	storage.Commit()
 	storage.Close()
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/gob"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// fraudReport is a fraud proof together with the server it was caught from. The
+// proof only shows that the ledger is invalid; that the server served it is the
+// word of the verifier that saved it, as the proof does not carry a commitment
+// signed by the server.
+type fraudReport struct {
+	Server string
+	Dim    int
+	Ledger string
+	Proof  game.FraudProof
+}
+
+// fraudReporter returns a function that saves the fraud proofs of the servers to
+// files in the directory.
+func fraudReporter(dir string, servers []string, deg int, ledger string) func(int, game.FraudProof) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalln(err)
+	}
+	return func(prover int, fp game.FraudProof) {
+		path := filepath.Join(dir, fmt.Sprintf("fraud-%d-%d.gob", time.Now().UnixNano(), prover))
+		log.Printf("server %v (%v) caught lying: %v, saving proof to %v\n", prover, servers[prover], fp.Reason, path)
+		f, err := os.Create(path)
+		if err != nil {
+			log.Println("error saving fraud proof:", err)
+			return
+		}
+		defer f.Close()
+		r := fraudReport{servers[prover], deg, ledger, fp}
+		if err := gob.NewEncoder(f).Encode(&r); err != nil {
+			log.Println("error saving fraud proof:", err)
+		}
+	}
+}
+
+func checkFraud(args []string) {
+	cmd := flag.NewFlagSet("checkfraud", flag.ExitOnError)
+	cmd.Parse(args)
+	if cmd.NArg() == 0 {
+		log.Fatalln("supply fraud proof files as command line arguments")
+	}
+
+	allValid := true
+	for _, path := range cmd.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		var r fraudReport
+		err = gob.NewDecoder(f).Decode(&r)
+		f.Close()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if r.Dim < 2 {
+			// the degree comes from the file, and the game is not defined below 2
+			fmt.Printf("%v: invalid, degree %v\n", path, r.Dim)
+			allValid = false
+			continue
+		}
+		if r.Ledger == "" {
+			// reports from before the ledger was recorded are of the test ledger
+			r.Ledger = "test"
+		}
+		if game.VerifyFraudProof(newHasher(r.Dim, r.Ledger), r.Dim, validTransition(r.Ledger), r.Proof) {
+			fmt.Printf("%v: valid, reported against server %v: %v\n", path, r.Server, r.Proof.Reason)
+		} else {
+			fmt.Printf("%v: invalid\n", path)
+			allValid = false
+		}
+	}
+	if !allValid {
+		os.Exit(1)
+	}
+}
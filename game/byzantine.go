@@ -0,0 +1,297 @@
+package game
+
+// Strategy is a way for a ByzantineSession to deviate from the protocol.
+type Strategy int
+
+const (
+	// WrongChildren flips a bit in every opening of children.
+	WrongChildren Strategy = iota
+	// LieSubtreeSizes reports the subtrees in the mountain range as Dim times
+	// larger than they are, which keeps the mountain range well-formed.
+	LieSubtreeSizes
+	// OpenWrongLeaf opens the leaf next to the disputed one.
+	OpenWrongLeaf
+	// ReplayChildren answers every request after the first one in a game with the
+	// first opening of the game.
+	ReplayChildren
+	// FalseNested claims that the ledger of the prover is nested in ours whenever
+	// we are the challenger, with the subtrees of our own ledger as the proof.
+	FalseNested
+	// Stall stops answering after the first message of every game.
+	Stall
+	// OutOfRangeChoice starts the game at a root the prover does not have whenever
+	// we are the challenger.
+	OutOfRangeChoice
+	// AgreeingChoice starts the game at a root that is the same as ours, or else
+	// picks a child that is the same as ours, whenever there is one and we are the
+	// challenger.
+	AgreeingChoice
+	// DisguisedChoice picks a child that is the same as ours, as AgreeingChoice,
+	// but claims a different node there, whenever we are the challenger.
+	DisguisedChoice
+	// MisplacedPrevLeaf opens the leaf two before the disputed one, with its
+	// genuine proof, as the one before it, which makes the transition valid if the
+	// ledger repeats a leaf.
+	MisplacedPrevLeaf
+)
+
+var Strategies = []Strategy{WrongChildren, LieSubtreeSizes, OpenWrongLeaf, ReplayChildren, FalseNested, Stall, OutOfRangeChoice, AgreeingChoice, DisguisedChoice, MisplacedPrevLeaf}
+
+func (s Strategy) String() string {
+	switch s {
+	case WrongChildren:
+		return "wrong children"
+	case LieSubtreeSizes:
+		return "lie subtree sizes"
+	case OpenWrongLeaf:
+		return "open wrong leaf"
+	case ReplayChildren:
+		return "replay children"
+	case FalseNested:
+		return "false nested"
+	case Stall:
+		return "stall"
+	case OutOfRangeChoice:
+		return "out of range choice"
+	case AgreeingChoice:
+		return "agreeing choice"
+	case DisguisedChoice:
+		return "disguised choice"
+	case MisplacedPrevLeaf:
+		return "misplaced prev leaf"
+	default:
+		return "unknown"
+	}
+}
+
+// ByzantineSession is a Session that deviates from the protocol following the
+// strategy, for testing that the verifier catches lying servers. Where the
+// strategy has nothing to say, it plays like an honest Session.
+type ByzantineSession struct {
+	Session
+	Strategy Strategy
+	Dim      int
+
+	// the first opening in the current game, for ReplayChildren
+	first Message
+}
+
+func (b *ByzantineSession) Run() {
+	defer close(b.O)
+	for msg := range b.I {
+		switch m := msg.(type) {
+		case GetMountainRange:
+			mr := b.mountainRange()
+			if b.Strategy == LieSubtreeSizes {
+				for i := range mr.Sizes {
+					mr.Sizes[i] *= b.Dim
+				}
+			}
+			b.O <- mr
+		case MountainRange:
+			b.runChallenger(m)
+		case StartRoot:
+			b.runResponder(m)
+		case OpenNode:
+			b.openNode(m)
+		case GetSuffix:
+			b.O <- NewSuffix(b.Tree, m.Index)
+		case GetTransition:
+			if m.Index < 0 || m.Index >= b.Tree.NumLeaves() {
+				b.O <- Terminate{}
+				continue
+			}
+			b.O <- ProveTransition(b.Tree, m.Index)
+		case Terminate:
+		default:
+			// we may have given up a game in the middle; ignore the rest of it
+		}
+	}
+}
+
+// stall ignores all messages until the game is terminated.
+func (b *ByzantineSession) stall() {
+	for req := range b.I {
+		if _, terminate := req.(Terminate); terminate {
+			return
+		}
+	}
+}
+
+func (b *ByzantineSession) runChallenger(mr MountainRange) {
+	switch b.Strategy {
+	case FalseNested:
+		b.O <- b.nestedLedger(mr)
+	case Stall:
+		rt, needGame := b.setStartPtr(mr)
+		if !needGame {
+			b.O <- b.nestedLedger(mr)
+			return
+		}
+		b.O <- rt
+		b.stall()
+	case OutOfRangeChoice:
+		b.O <- StartRoot{Index: len(mr.Roots)}
+	case AgreeingChoice, DisguisedChoice:
+		b.runAgreeingChallenger(mr)
+	default:
+		// play as an honest challenger; our ledger may be shorter than the one of
+		// the prover if we lie about the sizes, in which case the honest logic
+		// cannot continue, and we answer something the verifier does not expect
+		defer func() {
+			if r := recover(); r != nil {
+				b.O <- Terminate{}
+				b.stall()
+			}
+		}()
+		b.Session.runChallenger(mr)
+	}
+}
+
+// runAgreeingChallenger plays like an honest challenger, except that it starts at
+// the first root, or opens the first child, that is the same as ours if there is
+// one. With DisguisedChoice, it starts at a root that differs, and claims that the
+// child it opens differs.
+func (b *ByzantineSession) runAgreeingChallenger(mr MountainRange) {
+	rt, needGame := b.setStartPtr(mr)
+	if !needGame {
+		b.O <- b.nestedLedger(mr)
+		return
+	}
+	if rt.Index > 0 && b.Strategy == AgreeingChoice {
+		// the roots before the one that differs are the same as ours
+		b.O <- StartRoot{}
+		b.stall()
+		return
+	}
+	b.O <- rt
+	for b.level > 0 {
+		resp, ok := <-b.I
+		if !ok {
+			return
+		}
+		nc, correct := resp.(NextChildren)
+		if !correct {
+			return
+		}
+		ours, depth := b.descendants(len(nc.Hashes))
+		if len(ours) != len(nc.Hashes) {
+			return
+		}
+		choice := -1
+		for i := range ours {
+			if ours[i] == nc.Hashes[i] && ours[i] != zeroHash {
+				choice = i
+				break
+			}
+		}
+		if choice == -1 {
+			for i := range ours {
+				if ours[i] != nc.Hashes[i] {
+					choice = i
+					break
+				}
+			}
+		}
+		if choice == -1 {
+			return
+		}
+		b.descend(choice, ours[choice], depth)
+		claimed := ours
+		if b.Strategy == DisguisedChoice {
+			claimed = append([]Hash{}, ours...)
+			claimed[choice][0] ^= 1
+		}
+		b.O <- OpenNext{Index: choice, Hashes: claimed}
+	}
+}
+
+// open returns the answer to a request to open the node at the level and position
+// with the given depth.
+func (b *ByzantineSession) open(level, pos, depth int) Message {
+	var res Message
+	if level == 0 {
+		st := RevealTransition(b.Tree, pos)
+		if b.Strategy == OpenWrongLeaf {
+			st = RevealTransition(b.Tree, b.neighbor(pos))
+		}
+		if b.Strategy == MisplacedPrevLeaf && pos > 1 {
+			st.From, st.FromProof = b.Tree.GetData(pos-2), b.Tree.GetProof(pos-2)
+		}
+		res = st
+	} else {
+		children := Descendants(b.Tree, level, pos, depth)
+		if b.Strategy == WrongChildren {
+			children = append([]Hash{}, children...)
+			children[len(children)-1][0] ^= 1
+		}
+		res = NextChildren{children}
+	}
+	if b.Strategy == ReplayChildren {
+		if b.first != nil {
+			return b.first
+		}
+		b.first = res
+	}
+	return res
+}
+
+// neighbor returns the index of the leaf next to leaf i, or the one before if i
+// is the last.
+func (b *ByzantineSession) neighbor(i int) int {
+	if i+1 < b.Tree.NumLeaves() {
+		return i + 1
+	} else if i > 0 {
+		return i - 1
+	}
+	return i
+}
+
+func (b *ByzantineSession) runResponder(sr StartRoot) {
+	b.first = nil
+	level, pos := b.rootAt(sr.Index)
+	depth := sr.Depth
+	for sent := 0; ; sent++ {
+		if b.Strategy == Stall && sent > 0 {
+			b.stall()
+			return
+		}
+		b.O <- b.open(level, pos, depth)
+		if level == 0 {
+			return
+		}
+		req, ok := <-b.I
+		if !ok {
+			return
+		}
+		on, correct := req.(OpenNext)
+		if !correct {
+			// Terminate, or anything we do not understand
+			return
+		}
+		d := openLevels(b.Tree.GetDegree(), depth, level)
+		width := 1
+		for i := 0; i < d; i++ {
+			width *= b.Tree.GetDegree()
+		}
+		level -= d
+		pos = pos*width + on.Index
+		depth = on.Depth
+	}
+}
+
+func (b *ByzantineSession) openNode(on OpenNode) {
+	if b.Strategy == Stall && b.first != nil {
+		// never answer after the first request
+		return
+	}
+	if !HasNode(b.Tree, on.Hash, on.Level, on.Pos) {
+		b.O <- Terminate{}
+		return
+	}
+	res := b.open(on.Level, on.Pos, on.Depth)
+	if b.Strategy == Stall {
+		b.first = res
+	}
+	b.O <- res
+}
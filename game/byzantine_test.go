@@ -0,0 +1,156 @@
+package game
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+)
+
+// validTestTransition accepts the transitions of the ledgers built by generateTree
+// at the points where there is no diff: each leaf is the index of the leaf.
+func validTestTransition(from, to, witness []byte) bool {
+	if len(to) != 8 {
+		return false
+	}
+	if from == nil {
+		return binary.LittleEndian.Uint64(to) == 0
+	}
+	return len(from) == 8 && binary.LittleEndian.Uint64(to) == binary.LittleEndian.Uint64(from)+1
+}
+
+// startByzantine runs an honest Session over each of the honest trees followed by
+// a ByzantineSession over the liar tree, and returns a verifier connected to them
+// and a function that stops the sessions. The liar is at index len(honest).
+func startByzantine(dim int, strategy Strategy, liar MerkleTree, honest ...MerkleTree) (*Verifier, func()) {
+	v := &Verifier{
+		Dim:             dim,
+		MerkleHasher:    NewSHA256Hasher(dim),
+		Timeout:         100 * time.Millisecond,
+		ValidTransition: validTestTransition,
+	}
+	wg := &sync.WaitGroup{}
+	var toSessions []chan Message
+	var runners []interface{ Run() }
+	for _, tree := range append(honest, liar) {
+		i := make(chan Message, 100)
+		o := make(chan Message, 100)
+		v.To = append(v.To, i)
+		v.From = append(v.From, o)
+		toSessions = append(toSessions, i)
+		runners = append(runners, &Session{Tree: tree, I: i, O: o})
+	}
+	runners[len(runners)-1] = &ByzantineSession{
+		Session:  *runners[len(runners)-1].(*Session),
+		Strategy: strategy,
+		Dim:      dim,
+	}
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r interface{ Run() }) {
+			r.Run()
+			wg.Done()
+		}(r)
+	}
+	return v, func() {
+		for _, ch := range toSessions {
+			close(ch)
+		}
+		wg.Wait()
+	}
+}
+
+func TestByzantineProvers(t *testing.T) {
+	honest := generateTree(299, 5)
+	prefix := generateTree(150, 5)
+	// the ledgers of the liar are forks of the honest one, so that the transition
+	// at the fork is invalid
+	liars := []struct {
+		name       string
+		size, diff int
+	}{
+		{"shorter fork", 273, 100},
+		{"longer fork", 320, 100},
+		{"fork at 0", 310, 0},
+		{"fork at last", 299, 298},
+		{"shorter fork at 0", 40, 0},
+	}
+	for _, strategy := range Strategies {
+		for _, l := range liars {
+			liar := generateTree(l.size, 5, l.diff)
+			for _, parallel := range []bool{false, true} {
+				for _, depth := range []int{1, 3} {
+					for _, stateless := range []bool{false, true} {
+						v, stop := startByzantine(5, strategy, liar, honest, prefix)
+						v.Parallel = parallel
+						v.Stateless = stateless
+						v.Depth = depth
+						_, winner, _ := v.Run()
+						stop()
+						if winner != 0 {
+							t.Errorf("%v liar with %v (parallel %v, depth %v, stateless %v): honest server loses to %v", strategy, l.name, parallel, depth, stateless, winner)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMaliciousChallenger(t *testing.T) {
+	honest := generateTree(299, 5)
+	// the liars are longer, so they challenge the honest server; the one that forks
+	// at 200 has the same first root
+	for _, c := range []struct {
+		strategy Strategy
+		fork     int
+		expected Reason
+	}{
+		{OutOfRangeChoice, 100, ReasonChallengerIndex},
+		{AgreeingChoice, 200, ReasonChallengerAgrees},
+		{AgreeingChoice, 100, ReasonChallengerAgrees},
+		{DisguisedChoice, 100, ReasonChallengerClaims},
+		{FalseNested, 100, ReasonNestedProof},
+	} {
+		strategy, expected := c.strategy, c.expected
+		v, stop := startByzantine(5, strategy, generateTree(320, 5, c.fork), honest)
+		var results []MatchResult
+		v.ReportResult = func(r MatchResult) {
+			results = append(results, r)
+		}
+		_, winner, _ := v.Run()
+		stop()
+		if winner != 0 {
+			t.Errorf("%v: honest server loses to %v", strategy, winner)
+		}
+		if len(results) != 1 || results[0].Challenger != 1 || results[0].Reason != expected {
+			t.Errorf("%v: expected the challenger to lose because %q, got %+v", strategy, expected, results)
+		}
+	}
+}
+
+func TestMisplacedPrevLeaf(t *testing.T) {
+	// the liar repeats the leaf at 99 at 100, which is a valid transition from the
+	// leaf at 98 that it opens as the one before
+	data := func(i int) []byte {
+		if i >= 100 {
+			i--
+		}
+		bs := make([]byte, 8)
+		binary.LittleEndian.PutUint64(bs, uint64(i))
+		return bs
+	}
+	liar := NewKVMerkleTree(NewInMemoryMerkleTreeStorage(), data, 273, 5)
+	if !validTestTransition(data(98), data(100), nil) {
+		t.Fatal("repeated leaf does not follow the one two before")
+	}
+	for _, stateless := range []bool{false, true} {
+		v, stop := startByzantine(5, MisplacedPrevLeaf, liar, generateTree(299, 5))
+		v.Stateless = stateless
+		_, winner, stats := v.Run()
+		stop()
+		if winner != 0 || len(stats) != 1 || stats[0].Reason != ReasonPrevLeafProof {
+			t.Errorf("stateless %v: liar is not caught opening a misplaced leaf: winner %v, %+v", stateless, winner, stats)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cacheEntryOverhead is roughly what an entry costs in memory besides its value:
+// the key, the list element and the slot in the map.
+const cacheEntryOverhead = 96
+
+type cacheKey struct {
+	kind  byte // 'n' for a node, 'd' for the data of a leaf, 'r' for a root size
+	level int
+	pos   int
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	node  Hash
+	data  []byte
+	size  int
+	found bool
+}
+
+func (e *cacheEntry) cost() int {
+	return cacheEntryOverhead + len(e.data)
+}
+
+// CacheStats counts the lookups a CachedMerkleTreeStorage answered from the cache
+// and those it passed on to the storage behind it.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+	Bytes  int // the estimated memory the entries take
+}
+
+// CachedMerkleTreeStorage keeps the nodes, the data of the leaves and the sizes
+// of the roots it reads from another storage in memory, and evicts the least
+// recently used ones when they take more than the given number of bytes. Nodes
+// that do not exist are cached as well, as the tree asks for the empty subtrees of
+// the last root again and again. The numbers of leaves and roots are always kept.
+// Writes go to the storage behind it. It is safe for concurrent use.
+type CachedMerkleTreeStorage struct {
+	DiskBackedMerkleTreeStorage
+	limit int
+
+	lock      sync.Mutex
+	entries   map[cacheKey]*list.Element
+	order     *list.List // the most recently used entry first
+	stats     CacheStats
+	numLeaves int
+	numRoots  int
+}
+
+func NewCachedMerkleTreeStorage(s DiskBackedMerkleTreeStorage, limit int) *CachedMerkleTreeStorage {
+	return &CachedMerkleTreeStorage{
+		DiskBackedMerkleTreeStorage: s,
+		limit:                       limit,
+		entries:                     make(map[cacheKey]*list.Element),
+		order:                       list.New(),
+		numLeaves:                   s.getNumLeaves(),
+		numRoots:                    s.getNumRoots(),
+	}
+}
+
+// Stats returns the counters of the cache.
+func (s *CachedMerkleTreeStorage) Stats() CacheStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stats
+}
+
+// lookup returns the cached entry of key, or calls fill to read it from the
+// storage and caches it.
+func (s *CachedMerkleTreeStorage) lookup(key cacheKey, fill func(e *cacheEntry)) cacheEntry {
+	s.lock.Lock()
+	if el, ok := s.entries[key]; ok {
+		s.order.MoveToFront(el)
+		s.stats.Hits++
+		e := *el.Value.(*cacheEntry)
+		s.lock.Unlock()
+		return e
+	}
+	s.stats.Misses++
+	s.lock.Unlock()
+
+	// read without holding the lock, so that misses do not wait for each other
+	e := &cacheEntry{key: key}
+	fill(e)
+	s.insert(e)
+	return *e
+}
+
+func (s *CachedMerkleTreeStorage) insert(e *cacheEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if el, ok := s.entries[e.key]; ok {
+		s.stats.Bytes -= el.Value.(*cacheEntry).cost()
+		s.order.Remove(el)
+		delete(s.entries, e.key)
+	}
+	if e.cost() > s.limit {
+		return
+	}
+	s.entries[e.key] = s.order.PushFront(e)
+	s.stats.Bytes += e.cost()
+	for s.stats.Bytes > s.limit {
+		el := s.order.Back()
+		old := el.Value.(*cacheEntry)
+		s.stats.Bytes -= old.cost()
+		s.order.Remove(el)
+		delete(s.entries, old.key)
+	}
+}
+
+func (s *CachedMerkleTreeStorage) getNode(level, pos int) (Hash, bool) {
+	e := s.lookup(cacheKey{'n', level, pos}, func(e *cacheEntry) {
+		e.node, e.found = s.DiskBackedMerkleTreeStorage.getNode(level, pos)
+	})
+	return e.node, e.found
+}
+
+func (s *CachedMerkleTreeStorage) storeNode(level, pos int, h Hash) {
+	s.DiskBackedMerkleTreeStorage.storeNode(level, pos, h)
+	s.insert(&cacheEntry{key: cacheKey{'n', level, pos}, node: h, found: true})
+}
+
+func (s *CachedMerkleTreeStorage) deleteNode(level, pos int) {
+	s.DiskBackedMerkleTreeStorage.deleteNode(level, pos)
+	s.insert(&cacheEntry{key: cacheKey{'n', level, pos}})
+}
+
+func (s *CachedMerkleTreeStorage) getData(idx int) ([]byte, bool) {
+	e := s.lookup(cacheKey{'d', 0, idx}, func(e *cacheEntry) {
+		e.data, e.found = s.DiskBackedMerkleTreeStorage.getData(idx)
+	})
+	return e.data, e.found
+}
+
+func (s *CachedMerkleTreeStorage) appendData(data []byte) {
+	s.DiskBackedMerkleTreeStorage.appendData(data)
+	s.lock.Lock()
+	idx := s.numLeaves
+	s.numLeaves++
+	s.lock.Unlock()
+	// the data may have been looked up and found missing before
+	s.insert(&cacheEntry{key: cacheKey{'d', 0, idx}, data: data, found: true})
+}
+
+func (s *CachedMerkleTreeStorage) getNumLeaves() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.numLeaves
+}
+
+func (s *CachedMerkleTreeStorage) getRootSize(idx int) int {
+	e := s.lookup(cacheKey{'r', 0, idx}, func(e *cacheEntry) {
+		e.size = s.DiskBackedMerkleTreeStorage.getRootSize(idx)
+	})
+	return e.size
+}
+
+func (s *CachedMerkleTreeStorage) getNumRoots() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.numRoots
+}
+
+func (s *CachedMerkleTreeStorage) appendRoot(size int) {
+	s.DiskBackedMerkleTreeStorage.appendRoot(size)
+	s.lock.Lock()
+	s.numRoots++
+	s.lock.Unlock()
+}
+
+// truncate empties the cache, as it holds the data and root sizes that go away.
+func (s *CachedMerkleTreeStorage) truncate(numLeaves, numRoots int) {
+	s.DiskBackedMerkleTreeStorage.truncate(numLeaves, numRoots)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = make(map[cacheKey]*list.Element)
+	s.order.Init()
+	s.stats.Bytes = 0
+	s.numLeaves = numLeaves
+	s.numRoots = numRoots
+}
+
+var _ DiskBackedMerkleTreeStorage = &CachedMerkleTreeStorage{}
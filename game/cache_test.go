@@ -0,0 +1,45 @@
+package game
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCachedStorage(t *testing.T) {
+	data := func(i int) []byte {
+		return make([]byte, i%7)
+	}
+	expected := buildKVMerkleTree(NewInMemoryMerkleTreeStorage(), data, 500, 4, false)
+	for _, limit := range []int{0, 2000, 1 << 20} {
+		s := NewFlatFileMerkleTreeStorage(t.TempDir())
+		// build through the cache, then read through a new one
+		buildKVMerkleTree(NewCachedMerkleTreeStorage(s, limit), data, 500, 4, false)
+		c := NewCachedMerkleTreeStorage(s, limit)
+		m := OpenKVMerkleTree(c)
+		for round := 0; round < 2; round++ {
+			if !reflect.DeepEqual(m.GetRoots(), expected.GetRoots()) || !reflect.DeepEqual(m.GetRootSizes(), expected.GetRootSizes()) {
+				t.Fatalf("limit %v: wrong roots", limit)
+			}
+			for idx := 0; idx < 500; idx++ {
+				if !bytes.Equal(m.GetData(idx), expected.GetData(idx)) || !reflect.DeepEqual(m.GetProof(idx), expected.GetProof(idx)) {
+					t.Errorf("limit %v: wrong leaf %v", limit, idx)
+				}
+			}
+			if m.GetNode(3, 1, 321) != expected.GetNode(3, 1, 321) {
+				t.Errorf("limit %v: wrong node over a prefix", limit)
+			}
+		}
+		stats := c.Stats()
+		if stats.Bytes > limit {
+			t.Errorf("limit %v: cache takes %v bytes", limit, stats.Bytes)
+		}
+		if limit == 0 && stats.Hits != 0 {
+			t.Errorf("empty cache has %v hits", stats.Hits)
+		}
+		if limit == 1<<20 && stats.Hits < stats.Misses {
+			t.Errorf("large cache has %v hits and %v misses", stats.Hits, stats.Misses)
+		}
+		s.Close()
+	}
+}
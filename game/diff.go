@@ -0,0 +1,164 @@
+package game
+
+// DiffReport describes how the ledgers of the challenger and the prover of a match
+// diverge. The suffixes are the perfect subtrees that cover the leaves from
+// CommonPrefix to the end of each ledger, in the order of the leaves.
+type DiffReport struct {
+	Challenger, Prover int
+	FirstDiff          int // index of the first leaf that differs, -1 if the ledger of the prover is nested
+	CommonPrefix       int // number of leaves the ledgers have in common
+	ChallengerSize     int
+	ProverSize         int
+	ChallengerSuffix   []Hash
+	ProverSuffix       []Hash
+}
+
+// GetSuffix asks a server for the perfect subtrees that cover its ledger before and
+// after the leaf at Index.
+type GetSuffix struct {
+	Index  int
+	Ledger string // see GetMountainRange
+}
+
+// Suffix holds the perfect subtrees that cover the leaves before and after the
+// index in the GetSuffix request. Both lists are in the order of the leaves, and
+// the subtrees are the largest ones that fit.
+type Suffix struct {
+	Prefix []Hash
+	Suffix []Hash
+}
+
+// position is the level and the position in the level of a node.
+type position struct {
+	level, pos int
+}
+
+// cover returns the positions of the largest perfect subtrees that cover the
+// leaves from start to end, in the order of the leaves.
+func cover(dim, start, end int) []position {
+	var res []position
+	for start < end {
+		c, level := 1, 0
+		for start%(c*dim) == 0 && start+c*dim <= end {
+			c *= dim
+			level++
+		}
+		res = append(res, position{level, start / c})
+		start += c
+	}
+	return res
+}
+
+// NewSuffix collects the perfect subtrees of the tree before and after leaf idx.
+func NewSuffix(t MerkleTree, idx int) Suffix {
+	n := 0
+	for _, s := range NewMountainRange(t).Sizes {
+		n += s
+	}
+	if idx < 0 || idx > n {
+		// we do not have the leaf
+		return Suffix{}
+	}
+	dim := t.GetDegree()
+	var res Suffix
+	for _, p := range cover(dim, 0, idx) {
+		res.Prefix = append(res.Prefix, t.GetNode(p.level, p.pos, n))
+	}
+	for _, p := range cover(dim, idx, n) {
+		res.Suffix = append(res.Suffix, t.GetNode(p.level, p.pos, n))
+	}
+	return res
+}
+
+// checkSuffix tells if the subtrees before and after leaf idx are the ones that
+// the mountain range commits to, computing the hashes with mh.
+func (v *Verifier) checkSuffix(mh MerkleHasher, mr MountainRange, idx int, s Suffix) bool {
+	n := 0
+	for _, sz := range mr.Sizes {
+		n += sz
+	}
+	prefix, suffix := cover(v.Dim, 0, idx), cover(v.Dim, idx, n)
+	if idx > n || len(s.Prefix) != len(prefix) || len(s.Suffix) != len(suffix) {
+		return false
+	}
+	nodes := make(map[position]Hash)
+	for i, p := range prefix {
+		nodes[p] = s.Prefix[i]
+	}
+	for i, p := range suffix {
+		nodes[p] = s.Suffix[i]
+	}
+	// compute the roots from the subtrees
+	var node func(level, pos, limit int) (Hash, bool)
+	node = func(level, pos, limit int) (Hash, bool) {
+		c := 1
+		for i := 0; i < level; i++ {
+			c *= v.Dim
+		}
+		if pos*c >= limit {
+			return zeroHash, true
+		}
+		if h, ok := nodes[position{level, pos}]; ok && pos*c+c <= limit {
+			return h, true
+		}
+		if level == 0 {
+			return zeroHash, false
+		}
+		children := make([]Hash, v.Dim)
+		for i := range children {
+			var ok bool
+			children[i], ok = node(level-1, pos*v.Dim+i, limit)
+			if !ok {
+				return zeroHash, false
+			}
+		}
+		return mh.ComputeParent(children), true
+	}
+	start := 0
+	for i, r := range mr.Roots {
+		c := capacity(v.Dim, mr.Sizes[i])
+		h, ok := node(height(v.Dim, c), start/c, start+mr.Sizes[i])
+		if !ok || h != r {
+			return false
+		}
+		start += mr.Sizes[i]
+	}
+	return true
+}
+
+// diff asks both parties for their subtrees around the first leaf that differs,
+// and returns the report if both of them are consistent with their mountain
+// ranges and agree on the common prefix.
+func (m *match) diff(cmr, pmr MountainRange, firstDiff int) (DiffReport, bool) {
+	r := DiffReport{
+		Challenger: m.cidx,
+		Prover:     m.pidx,
+		FirstDiff:  firstDiff,
+	}
+	for _, s := range cmr.Sizes {
+		r.ChallengerSize += s
+	}
+	for _, s := range pmr.Sizes {
+		r.ProverSize += s
+	}
+	r.CommonPrefix = firstDiff
+	if firstDiff == -1 {
+		r.CommonPrefix = r.ProverSize
+	}
+	m.send(m.cidx, GetSuffix{r.CommonPrefix, m.Ledger})
+	m.send(m.pidx, GetSuffix{r.CommonPrefix, m.Ledger})
+	// receive both answers before checking, so that no answer is left behind
+	cs, cok := m.recv(m.cidx).(Suffix)
+	ps, pok := m.recv(m.pidx).(Suffix)
+	if !cok || !pok || !m.checkSuffix(m.hasher, cmr, r.CommonPrefix, cs) || !m.checkSuffix(m.hasher, pmr, r.CommonPrefix, ps) {
+		return r, false
+	}
+	for i := range cs.Prefix {
+		if cs.Prefix[i] != ps.Prefix[i] {
+			return r, false
+		}
+	}
+	r.ChallengerSuffix = cs.Suffix
+	r.ProverSuffix = ps.Suffix
+	return r, true
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffReport(t *testing.T) {
+	honest := generateTree(299, 5)
+	fork := generateTreeLayout(320, 5, true, 100)
+	prefix := generateTreeLayout(150, 5, true)
+	v, stop := startSessions(5, honest, fork, prefix)
+	v.Diff = true
+	var reports []DiffReport
+	v.ReportDiff = func(r DiffReport) {
+		reports = append(reports, r)
+	}
+	v.Run()
+	stop()
+
+	expected := []DiffReport{
+		{
+			Challenger:       1,
+			Prover:           0,
+			FirstDiff:        100,
+			CommonPrefix:     100,
+			ChallengerSize:   320,
+			ProverSize:       299,
+			ChallengerSuffix: NewSuffix(fork, 100).Suffix,
+			ProverSuffix:     NewSuffix(honest, 100).Suffix,
+		},
+		{
+			Challenger:       0,
+			Prover:           2,
+			FirstDiff:        -1,
+			CommonPrefix:     150,
+			ChallengerSize:   299,
+			ProverSize:       150,
+			ChallengerSuffix: NewSuffix(honest, 150).Suffix,
+		},
+	}
+	if !reflect.DeepEqual(reports, expected) {
+		t.Errorf("expected reports %+v, got %+v", expected, reports)
+	}
+}
+
+func TestSuffixLayouts(t *testing.T) {
+	v := &Verifier{Dim: 3, MerkleHasher: NewSHA256Hasher(3)}
+	classic := generateTree(100, 3)
+	single := generateTreeLayout(100, 3, true)
+	for _, idx := range []int{0, 1, 27, 55, 99, 100} {
+		cs, ss := NewSuffix(classic, idx), NewSuffix(single, idx)
+		if !reflect.DeepEqual(cs, ss) {
+			t.Errorf("subtrees around leaf %v depend on the layout", idx)
+		}
+		if !v.checkSuffix(v.MerkleHasher, NewMountainRange(classic), idx, cs) || !v.checkSuffix(v.MerkleHasher, NewMountainRange(single), idx, ss) {
+			t.Errorf("subtrees around leaf %v do not pass check", idx)
+		}
+		if len(ss.Suffix) > 0 {
+			ss.Suffix[len(ss.Suffix)-1][0] ^= 1
+			if v.checkSuffix(v.MerkleHasher, NewMountainRange(single), idx, ss) {
+				t.Errorf("tampered subtrees around leaf %v pass check", idx)
+			}
+		}
+	}
+}
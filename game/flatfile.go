@@ -0,0 +1,300 @@
+package game
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// flatArray is an array of elements of a fixed size in a file. The committed part
+// of the file is mapped into memory, and elements appended after it are kept in
+// pending until the next flush.
+type flatArray struct {
+	file    *os.File
+	elem    int
+	mapped  []byte
+	pending []byte
+}
+
+func openFlatArray(path string, elem int) *flatArray {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		panic(err)
+	}
+	a := &flatArray{file: f, elem: elem}
+	a.remap()
+	return a
+}
+
+// remap maps the file again after it has grown.
+func (a *flatArray) remap() {
+	if a.mapped != nil {
+		if err := unmapFile(a.mapped); err != nil {
+			panic(err)
+		}
+		a.mapped = nil
+	}
+	info, err := a.file.Stat()
+	if err != nil {
+		panic(err)
+	}
+	if info.Size() == 0 {
+		return
+	}
+	a.mapped, err = mapFile(a.file, int(info.Size()))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (a *flatArray) count() int {
+	return (len(a.mapped) + len(a.pending)) / a.elem
+}
+
+// slice returns n bytes from offset off, or false if they are beyond the end. The
+// bytes must not cross the end of the mapped part, which holds for elements, and
+// for anything appended in one piece.
+func (a *flatArray) slice(off, n int) ([]byte, bool) {
+	if off+n <= len(a.mapped) {
+		return a.mapped[off : off+n], true
+	}
+	off -= len(a.mapped)
+	if off < 0 || off+n > len(a.pending) {
+		return nil, false
+	}
+	return a.pending[off : off+n], true
+}
+
+// get returns element i, or nil if it is beyond the end.
+func (a *flatArray) get(i int) []byte {
+	b, _ := a.slice(i*a.elem, a.elem)
+	return b
+}
+
+// set writes element i, padding the array with zeros if it is beyond the end.
+func (a *flatArray) set(i int, b []byte) {
+	off := i * a.elem
+	if off >= len(a.mapped) {
+		off -= len(a.mapped)
+		for len(a.pending) < off+a.elem {
+			a.pending = append(a.pending, 0)
+		}
+		copy(a.pending[off:off+a.elem], b)
+		return
+	}
+	if _, err := a.file.WriteAt(b[:a.elem], int64(off)); err != nil {
+		panic(err)
+	}
+	if !mappingShared {
+		copy(a.mapped[off:off+a.elem], b)
+	}
+}
+
+func (a *flatArray) append(b []byte) {
+	a.pending = append(a.pending, b...)
+}
+
+// flush writes the pending elements to the file and maps it again.
+func (a *flatArray) flush() {
+	if len(a.pending) == 0 {
+		return
+	}
+	if _, err := a.file.WriteAt(a.pending, int64(len(a.mapped))); err != nil {
+		panic(err)
+	}
+	a.pending = nil
+	if err := a.file.Sync(); err != nil {
+		panic(err)
+	}
+	a.remap()
+}
+
+// truncate removes the elements from n on.
+func (a *flatArray) truncate(n int) {
+	a.flush()
+	if n >= a.count() {
+		return
+	}
+	if err := a.file.Truncate(int64(n * a.elem)); err != nil {
+		panic(err)
+	}
+	a.remap()
+}
+
+func (a *flatArray) close() {
+	a.flush()
+	if a.mapped != nil {
+		if err := unmapFile(a.mapped); err != nil {
+			panic(err)
+		}
+		a.mapped = nil
+	}
+	a.file.Close()
+}
+
+func (a *flatArray) getUint64(i int) (uint64, bool) {
+	b := a.get(i)
+	if b == nil {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(b), true
+}
+
+func (a *flatArray) appendUint64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	a.append(buf[:])
+}
+
+// FlatFileMerkleTreeStorage stores a tree in a directory of flat files, which are
+// memory mapped where the platform supports it. Nodes are addressed by position,
+// so each level is a file of hashes in the order of their positions, with zeros
+// where there is no node. The data of the leaves is stored one after another in
+// one file, and the offsets of their ends in another.
+type FlatFileMerkleTreeStorage struct {
+	dir     string
+	levels  []*flatArray
+	data    *flatArray
+	offsets *flatArray
+	roots   *flatArray
+	meta    *flatArray
+}
+
+func NewFlatFileMerkleTreeStorage(dir string) *FlatFileMerkleTreeStorage {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+	s := &FlatFileMerkleTreeStorage{
+		dir:     dir,
+		data:    openFlatArray(filepath.Join(dir, "data"), 1),
+		offsets: openFlatArray(filepath.Join(dir, "offsets"), 8),
+		roots:   openFlatArray(filepath.Join(dir, "roots"), 8),
+		meta:    openFlatArray(filepath.Join(dir, "meta"), 8),
+	}
+	for {
+		path := s.levelPath(len(s.levels))
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		s.levels = append(s.levels, openFlatArray(path, 32))
+	}
+	return s
+}
+
+func (s *FlatFileMerkleTreeStorage) levelPath(level int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("level-%d", level))
+}
+
+func (s *FlatFileMerkleTreeStorage) files() []*flatArray {
+	return append([]*flatArray{s.data, s.offsets, s.roots, s.meta}, s.levels...)
+}
+
+func (s *FlatFileMerkleTreeStorage) Commit() {
+	for _, a := range s.files() {
+		a.flush()
+	}
+}
+
+func (s *FlatFileMerkleTreeStorage) Close() {
+	for _, a := range s.files() {
+		a.close()
+	}
+}
+
+func (s *FlatFileMerkleTreeStorage) GetDegree() int {
+	res, ok := s.meta.getUint64(0)
+	if !ok || res == 0 {
+		panic("key does not exist or value is invalid")
+	}
+	return int(res)
+}
+
+func (s *FlatFileMerkleTreeStorage) StoreDegree(d int) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(d))
+	s.meta.set(0, buf[:])
+}
+
+func (s *FlatFileMerkleTreeStorage) getNode(level, pos int) (Hash, bool) {
+	if level >= len(s.levels) {
+		return Hash{}, false
+	}
+	b := s.levels[level].get(pos)
+	if b == nil {
+		return Hash{}, false
+	}
+	var h Hash
+	copy(h[:], b)
+	return h, h != zeroHash
+}
+
+func (s *FlatFileMerkleTreeStorage) storeNode(level, pos int, h Hash) {
+	for len(s.levels) <= level {
+		s.levels = append(s.levels, openFlatArray(s.levelPath(len(s.levels)), 32))
+	}
+	s.levels[level].set(pos, h[:])
+}
+
+func (s *FlatFileMerkleTreeStorage) deleteNode(level, pos int) {
+	if level >= len(s.levels) || pos >= s.levels[level].count() {
+		return
+	}
+	s.levels[level].set(pos, zeroHash[:])
+}
+
+func (s *FlatFileMerkleTreeStorage) getData(idx int) ([]byte, bool) {
+	end, ok := s.offsets.getUint64(idx)
+	if !ok {
+		return nil, false
+	}
+	start := uint64(0)
+	if idx > 0 {
+		start, _ = s.offsets.getUint64(idx - 1)
+	}
+	b, ok := s.data.slice(int(start), int(end-start))
+	if !ok {
+		return nil, false
+	}
+	// the mapping goes away when the file grows, so we do not hand it out
+	return append([]byte{}, b...), true
+}
+
+func (s *FlatFileMerkleTreeStorage) appendData(data []byte) {
+	s.data.append(data)
+	s.offsets.appendUint64(uint64(s.data.count()))
+}
+
+func (s *FlatFileMerkleTreeStorage) getNumLeaves() int {
+	return s.offsets.count()
+}
+
+func (s *FlatFileMerkleTreeStorage) getRootSize(idx int) int {
+	size, ok := s.roots.getUint64(idx)
+	if !ok {
+		panic("index does not exist")
+	}
+	return int(size)
+}
+
+func (s *FlatFileMerkleTreeStorage) getNumRoots() int {
+	return s.roots.count()
+}
+
+func (s *FlatFileMerkleTreeStorage) appendRoot(size int) {
+	s.roots.appendUint64(uint64(size))
+}
+
+func (s *FlatFileMerkleTreeStorage) truncate(numLeaves, numRoots int) {
+	if numLeaves < s.offsets.count() {
+		end := uint64(0)
+		if numLeaves > 0 {
+			end, _ = s.offsets.getUint64(numLeaves - 1)
+		}
+		s.data.truncate(int(end))
+		s.offsets.truncate(numLeaves)
+	}
+	s.roots.truncate(numRoots)
+}
+
+var _ DiskBackedMerkleTreeStorage = &FlatFileMerkleTreeStorage{}
@@ -0,0 +1,86 @@
+package game
+
+// FraudProof is the evidence that a prover opened its commitment inconsistently
+// during a match. It contains the mountain range the prover committed to and
+// everything the prover opened in the match, so that anyone can check it without
+// talking to the prover.
+//
+// The mountain range is not signed, so a proof only shows that the ledger under
+// the mountain range is invalid, not who served it. Only the verifier that played
+// the match knows which server that was.
+type FraudProof struct {
+	Range    MountainRange    // the mountain range of the prover
+	Start    int              // index of the root where the game started
+	Depth    int              // the number of levels opened at once
+	Openings [][]Hash         // descendants opened by the prover, from the root downwards
+	Choices  []int            // index of the descendant to open next after each opening
+	Leaf     *StateTransition // the opened leaf, if the game reached the leaf level
+	Reason   Reason           // the check that failed
+}
+
+// IsFraud tells if the reason is a failed check of what the prover opened, as
+// opposed to e.g. a message of the wrong type, which we have no evidence of.
+func (r Reason) IsFraud() bool {
+	switch r {
+	case ReasonChildrenMismatch, ReasonNonEmptyPadding, ReasonLeafMismatch, ReasonWeightMismatch, ReasonPrevLeafProof, ReasonPrevLeafAtZero, ReasonInvalidTransition:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *match) fraudProof(pmr MountainRange, reason Reason) FraudProof {
+	return FraudProof{
+		Range:    pmr,
+		Start:    m.start,
+		Depth:    m.depth,
+		Openings: m.openings,
+		Choices:  m.choices,
+		Leaf:     m.leaf,
+		Reason:   reason,
+	}
+}
+
+// VerifyFraudProof replays the match of the fraud proof against a challenger that
+// opens the recorded choices, and tells if the prover fails the check stated in
+// the proof at its last opening. Everything opened before must be consistent with
+// the mountain range, so that a valid proof is about the ledger under the mountain
+// range and not about made-up openings. It does not tell who served the ledger,
+// see FraudProof. The transitions are checked with validTransition,
+// which must be the one of the ledger that the match was played over.
+func VerifyFraudProof(mh MerkleHasher, dim int, validTransition func(from, to, witness []byte) bool, fp FraudProof) bool {
+	if dim < 2 || len(fp.Range.Roots) != len(fp.Range.Sizes) {
+		return false
+	}
+	const challenger, prover = 0, 1
+	r := MatchRecord{
+		Challenger: challenger,
+		Prover:     prover,
+		Range:      fp.Range,
+		Depth:      fp.Depth,
+	}
+	add := func(from int, msg Message) {
+		r.Entries = append(r.Entries, TranscriptEntry{From: from, To: VerifierIndex, Message: msg})
+	}
+	// the proof does not carry the mountain range and the nodes of the challenger;
+	// the challenger only proves its nodes after the prover opens a valid leaf, so
+	// any nodes that differ from the ones of the prover pass
+	add(challenger, StartRoot{Index: fp.Start})
+	for i, o := range fp.Openings {
+		add(prover, NextChildren{o})
+		if i < len(fp.Choices) {
+			claimed := make([]Hash, len(o))
+			for j := range claimed {
+				claimed[j] = o[j]
+				claimed[j][0] ^= 1
+			}
+			add(challenger, OpenNext{Index: fp.Choices[i], Hashes: claimed})
+		}
+	}
+	if fp.Leaf != nil {
+		add(prover, *fp.Leaf)
+	}
+	v := &Verifier{Dim: dim, MerkleHasher: mh, ValidTransition: validTransition}
+	winner, reason, consumed := v.replay(r)
+	return consumed && winner == challenger && reason == fp.Reason && reason.IsFraud()
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"testing"
+)
+
+// relay forwards the messages from in to out, passing each through f.
+func relay(in <-chan Message, f func(Message) Message) <-chan Message {
+	out := make(chan Message, 100)
+	go func() {
+		defer close(out)
+		for m := range in {
+			out <- f(m)
+		}
+	}()
+	return out
+}
+
+func TestFraudProof(t *testing.T) {
+	v, stop := startSessions(5, generateTree(299, 5), generateTree(273, 5, 100))
+	defer stop()
+	// the second server flips a bit in the last child of the third opening, which
+	// is at the level right above the leaves
+	openings := 0
+	v.From[1] = relay(v.From[1], func(m Message) Message {
+		if nc, ok := m.(NextChildren); ok {
+			openings += 1
+			if openings != 3 {
+				return m
+			}
+			hashes := append([]Hash{}, nc.Hashes...)
+			hashes[len(hashes)-1][0] ^= 1
+			return NextChildren{hashes}
+		}
+		return m
+	})
+	var proofs []FraudProof
+	v.ReportFraud = func(prover int, fp FraudProof) {
+		if prover != 1 {
+			t.Error("fraud reported for the honest server")
+		}
+		proofs = append(proofs, fp)
+	}
+	if winner := v.Match(0, 1, NewMountainRange(generateTree(273, 5, 100)), 0); winner != 0 {
+		t.Fatal("lying server wins the match")
+	}
+	if len(proofs) != 1 {
+		t.Fatal("fraud proof not reported")
+	}
+	fp := proofs[0]
+	mh := NewSHA256Hasher(5)
+	if !VerifyFraudProof(mh, 5, nil, fp) {
+		t.Error("valid fraud proof does not pass check")
+	}
+
+	// a proof against a different commitment, or claiming a different check, must fail
+	forged := fp
+	forged.Range = NewMountainRange(generateTree(273, 5, 200))
+	if VerifyFraudProof(mh, 5, nil, forged) {
+		t.Error("fraud proof against a different commitment passes check")
+	}
+	forged = fp
+	forged.Reason = ReasonLeafMismatch
+	if VerifyFraudProof(mh, 5, nil, forged) {
+		t.Error("fraud proof with a different reason passes check")
+	}
+	forged = fp
+	forged.Depth = 2
+	if VerifyFraudProof(mh, 5, nil, forged) {
+		t.Error("fraud proof with a different depth passes check")
+	}
+	for _, dim := range []int{-1, 0, 1} {
+		if VerifyFraudProof(mh, dim, nil, fp) {
+			t.Errorf("fraud proof passes check with degree %v", dim)
+		}
+	}
+	forged = fp
+	forged.Openings = fp.Openings[:len(fp.Openings)-1]
+	if VerifyFraudProof(mh, 5, nil, forged) {
+		t.Error("truncated fraud proof passes check")
+	}
+}
+
+func TestFraudProofHonestOpening(t *testing.T) {
+	// a transcript of an honest prover is not a fraud proof, whatever it claims
+	tree := generateTree(125, 5)
+	mr := NewMountainRange(tree)
+	fp := FraudProof{Range: mr, Start: 0, Reason: ReasonChildrenMismatch}
+	level, pos := 3, 0
+	for level > 0 {
+		fp.Openings = append(fp.Openings, Descendants(tree, level, pos, 1))
+		fp.Choices = append(fp.Choices, 2)
+		level, pos = level-1, pos*5+2
+	}
+	st := RevealTransition(tree, pos)
+	fp.Leaf = &st
+	for _, reason := range []Reason{ReasonChildrenMismatch, ReasonLeafMismatch, ReasonPrevLeafProof, ReasonPrevLeafAtZero} {
+		fp.Reason = reason
+		if VerifyFraudProof(NewSHA256Hasher(5), 5, nil, fp) {
+			t.Errorf("honest opening passes check as %v", reason)
+		}
+	}
+}
+
+func TestFraudProofInvalidTransition(t *testing.T) {
+	// the longer ledger has an invalid transition at 150, which only the validator
+	// of the ledger tells
+	v, stop := startSessions(5, generateTree(200, 5), generateTree(299, 5, 150))
+	defer stop()
+	v.ValidTransition = validTestTransition
+	var proofs []FraudProof
+	v.ReportFraud = func(prover int, fp FraudProof) {
+		proofs = append(proofs, fp)
+	}
+	if winner := v.Match(0, 1, NewMountainRange(generateTree(299, 5, 150)), 0); winner != 0 {
+		t.Fatal("invalid ledger wins the match")
+	}
+	if len(proofs) != 1 || proofs[0].Reason != ReasonInvalidTransition {
+		t.Fatalf("fraud proof of the invalid transition not reported: %v", proofs)
+	}
+	mh := NewSHA256Hasher(5)
+	if !VerifyFraudProof(mh, 5, validTestTransition, proofs[0]) {
+		t.Error("valid fraud proof does not pass check")
+	}
+	if VerifyFraudProof(mh, 5, nil, proofs[0]) {
+		t.Error("fraud proof passes check without the validator of the ledger")
+	}
+}
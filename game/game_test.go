@@ -1,12 +1,23 @@
 package game
 
 import (
+	"bytes"
+	"math/rand"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestFindDiff(t *testing.T) {
+	testFindDiff(t, false)
+}
+
+func TestFindDiffStateless(t *testing.T) {
+	testFindDiff(t, true)
+}
+
+func testFindDiff(t *testing.T, stateless bool) {
 	for diffIdx := 0; diffIdx < 299; diffIdx += 1 {
 		tree1 := generateTree(273, 5)
 		tree2 := generateTree(299, 5, diffIdx)
@@ -16,8 +27,8 @@ func TestFindDiff(t *testing.T) {
 		vp1 := make(chan Message, 100)
 		vp2 := make(chan Message, 100)
 
-		p1 := &Session{tree1, vp1, p1v, Hash{}}
-		p2 := &Session{tree2, vp2, p2v, Hash{}}
+		p1 := &Session{Tree: tree1, I: vp1, O: p1v}
+		p2 := &Session{Tree: tree2, I: vp2, O: p2v}
 		wg := &sync.WaitGroup{}
 		wg.Add(2)
 		go func() {
@@ -33,8 +44,9 @@ func TestFindDiff(t *testing.T) {
 			From:         []<-chan Message{p1v, p2v},
 			Dim:          5,
 			MerkleHasher: NewSHA256Hasher(5),
+			Stateless:    stateless,
 		}
-		mr, _ := v.Run()
+		mr, _, _ := v.Run()
 		var correct MountainRange
 		if diffIdx >= 273 {
 			// player 1 should win, because we do not check state transition for now, and it plays by the rule all the time
@@ -50,3 +62,590 @@ func TestFindDiff(t *testing.T) {
 		wg.Wait()
 	}
 }
+
+// startSessions runs a Session over each of the trees, and returns a verifier
+// connected to them and a function that stops the sessions.
+func startSessions(dim int, trees ...MerkleTree) (*Verifier, func()) {
+	var ledgers []map[string]MerkleTree
+	for _, tree := range trees {
+		ledgers = append(ledgers, map[string]MerkleTree{"": tree})
+	}
+	return startLedgerSessions(dim, ledgers...)
+}
+
+// startLedgerSessions is startSessions for sessions that host several ledgers by
+// name, where the empty name is the default ledger.
+func startLedgerSessions(dim int, ledgers ...map[string]MerkleTree) (*Verifier, func()) {
+	v := &Verifier{
+		Dim:          dim,
+		MerkleHasher: NewSHA256Hasher(dim),
+	}
+	wg := &sync.WaitGroup{}
+	var toSessions []chan Message
+	for _, l := range ledgers {
+		i := make(chan Message, 100)
+		o := make(chan Message, 100)
+		s := &Session{Tree: l[""], Ledgers: l, I: i, O: o}
+		wg.Add(1)
+		go func() {
+			s.Run()
+			wg.Done()
+		}()
+		v.To = append(v.To, i)
+		v.From = append(v.From, o)
+		toSessions = append(toSessions, i)
+	}
+	return v, func() {
+		for _, ch := range toSessions {
+			close(ch)
+		}
+		wg.Wait()
+	}
+}
+
+// startBalancedSessions is startLedgerSessions, but every OpenNode reaches a new
+// session of the server, as a load balancer in front of stateless provers may
+// send it on another connection.
+func startBalancedSessions(dim int, ledgers ...map[string]MerkleTree) (*Verifier, func()) {
+	v, stop := startLedgerSessions(dim, ledgers...)
+	routers, forwarders := &sync.WaitGroup{}, &sync.WaitGroup{}
+	var ins []chan Message
+	for idx, l := range ledgers {
+		l := l
+		in := make(chan Message, 100)
+		out := make(chan Message, 100)
+		toSession, fromSession := v.To[idx], v.From[idx]
+		v.To[idx], v.From[idx] = in, out
+		ins = append(ins, in)
+		routers.Add(1)
+		go func() {
+			for msg := range in {
+				if _, ok := msg.(OpenNode); !ok {
+					toSession <- msg
+					continue
+				}
+				i := make(chan Message, 1)
+				o := make(chan Message, 1)
+				go (&Session{Tree: l[""], Ledgers: l, I: i, O: o}).Run()
+				i <- msg
+				close(i)
+				out <- <-o
+			}
+			routers.Done()
+		}()
+		forwarders.Add(1)
+		go func() {
+			for msg := range fromSession {
+				out <- msg
+			}
+			forwarders.Done()
+		}()
+	}
+	return v, func() {
+		for _, ch := range ins {
+			close(ch)
+		}
+		routers.Wait()
+		stop()
+		forwarders.Wait()
+	}
+}
+
+func TestNamedLedgers(t *testing.T) {
+	for _, mode := range []struct {
+		name      string
+		start     func(int, ...map[string]MerkleTree) (*Verifier, func())
+		stateless bool
+	}{
+		{"stateful", startLedgerSessions, false},
+		{"stateless", startLedgerSessions, true},
+		{"stateless across sessions", startBalancedSessions, true},
+	} {
+		// the servers take turns to host the shorter ledger, and the longer ones
+		// fork from it
+		v, stop := mode.start(3,
+			map[string]MerkleTree{"": generateTree(80, 3), "b": generateTree(120, 3, 20)},
+			map[string]MerkleTree{"": generateTree(100, 3, 10), "b": generateTree(50, 3)},
+		)
+		v.Stateless = mode.stateless
+		v.Diff = true
+		var results []MatchResult
+		v.ReportResult = func(r MatchResult) {
+			results = append(results, r)
+		}
+		for _, c := range []struct {
+			ledger string
+			winner int
+		}{{"", 0}, {"b", 1}, {"", 0}, {"c", NoWinner}, {"b", 1}} {
+			v.Ledger = c.ledger
+			results = nil
+			if _, winner, _ := v.Run(); winner != c.winner {
+				t.Errorf("%v: winner of ledger %q is %v instead of %v", mode.name, c.ledger, winner, c.winner)
+			}
+			// servers that do not host the ledger do not lose to the verifier
+			for _, r := range results {
+				if (c.ledger == "c") != (r.Reason == ReasonUnsupportedLedger) || (r.Reason == ReasonUnsupportedLedger && r.Winner != NoWinner) {
+					t.Errorf("%v: incorrect result %+v for ledger %q", mode.name, r, c.ledger)
+				}
+			}
+			if c.ledger == "c" && len(results) != 2 {
+				t.Errorf("%v: %v results for the missing ledger instead of 2", mode.name, len(results))
+			}
+		}
+		stop()
+	}
+}
+
+func TestMatchAfterRun(t *testing.T) {
+	// Match does not take the mountain ranges that an earlier Run fetched, here of
+	// another ledger, as the ones the challenger committed to, and the challenger
+	// plays over the ledger of the match
+	v, stop := startLedgerSessions(3,
+		map[string]MerkleTree{"": generateTree(60, 3), "b": generateTree(100, 3)},
+		map[string]MerkleTree{"": generateTree(50, 3), "b": generateTree(90, 3, 40)},
+	)
+	defer stop()
+	var results []MatchResult
+	v.ReportResult = func(r MatchResult) {
+		results = append(results, r)
+	}
+	v.Run()
+	v.Ledger = "b"
+	results = nil
+	if winner := v.Match(0, 1, NewMountainRange(generateTree(90, 3, 40)), 1); winner != 1 || len(results) != 1 || results[0].Reason != ReasonValidTransition {
+		t.Errorf("winner %v, %+v", winner, results)
+	}
+}
+
+func TestParallelTournament(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		// the honest server has the longest ledger among those that play by the rule
+		// and are consistent; the others are prefixes of it, or forks of it that are
+		// longer, so that the honest server wins all the matches it plays
+		honestSize := 50 + rng.Intn(100)
+		var trees []MerkleTree
+		honest := rng.Intn(8)
+		sizes := rng.Perm(honestSize)
+		for i := 0; i < 8; i++ {
+			if i == honest {
+				trees = append(trees, generateTree(honestSize, 3))
+			} else if rng.Intn(2) == 0 {
+				trees = append(trees, generateTree(sizes[i], 3))
+			} else {
+				trees = append(trees, generateTree(honestSize+1+sizes[i], 3, rng.Intn(honestSize)))
+			}
+		}
+		v, stop := startSessions(3, trees...)
+		seqMr, seqWinner, _ := v.Run()
+		v.Parallel = true
+		parMr, parWinner, _ := v.Run()
+		stop()
+		if seqWinner != parWinner || !reflect.DeepEqual(seqMr, parMr) {
+			t.Errorf("round %v: sequential winner %v, parallel winner %v", round, seqWinner, parWinner)
+		}
+		if parWinner != honest {
+			t.Errorf("round %v: honest server %v eliminated", round, honest)
+		}
+	}
+}
+
+func TestParallelReports(t *testing.T) {
+	// the matches of a round run at the same time, but their reports do not
+	var trees []MerkleTree
+	for i := 0; i < 8; i++ {
+		trees = append(trees, generateTree(100+i, 3, 50))
+	}
+	v, stop := startSessions(3, trees...)
+	defer stop()
+	v.Parallel = true
+	v.Diff = true
+	busy := false
+	check := func() {
+		if busy {
+			t.Error("reports overlap")
+		}
+		busy = true
+		time.Sleep(time.Millisecond)
+		busy = false
+	}
+	v.ReportResult = func(r MatchResult) { check() }
+	v.ReportDiff = func(r DiffReport) { check() }
+	v.Run()
+}
+
+func TestRandomSizes(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for round := 0; round < 50; round++ {
+		// the servers have random sizes and layouts; the honest ledger is the longest
+		// among the ledgers with valid transitions, and the others are prefixes or
+		// forks of it
+		dim := 2 + rng.Intn(6)
+		honestSize := 1 + rng.Intn(300)
+		trees := []MerkleTree{generateTreeLayout(honestSize, dim, rng.Intn(2) == 0)}
+		for i := 0; i < 5; i++ {
+			size := 1 + rng.Intn(2*honestSize)
+			if size < honestSize && rng.Intn(2) == 0 {
+				trees = append(trees, generateTreeLayout(size, dim, rng.Intn(2) == 0))
+			} else {
+				fork := rng.Intn(size)
+				if fork > honestSize-1 {
+					fork = honestSize - 1
+				}
+				trees = append(trees, generateTreeLayout(size, dim, rng.Intn(2) == 0, fork))
+			}
+		}
+		for _, parallel := range []bool{false, true} {
+			v, stop := startSessions(dim, trees...)
+			v.ValidTransition = validTestTransition
+			v.Parallel = parallel
+			v.Depth = rng.Intn(4)
+			_, winner, _ := v.Run()
+			stop()
+			if winner != 0 {
+				t.Errorf("round %v (dim %v, parallel %v, depth %v): honest server loses to %v", round, dim, parallel, v.Depth, winner)
+			}
+		}
+	}
+}
+
+func TestBatchedOpenings(t *testing.T) {
+	// the game over a root of 5^4 leaves takes 4 rounds when opening one level at
+	// a time, and 2 when opening 3 levels at a time
+	for depth, rounds := range map[int]int{0: 4, 1: 4, 2: 2, 3: 2, 4: 1, 9: 1} {
+		v, stop := startSessions(5, generateTree(625, 5), generateTree(625, 5, 300))
+		v.Depth = depth
+		v.Transcript = &Transcript{}
+		v.Run()
+		stop()
+		if len(v.Transcript.Matches) != 1 {
+			t.Fatalf("depth %v: expected one match, got %v", depth, len(v.Transcript.Matches))
+		}
+		n := 0
+		for _, e := range v.Transcript.Matches[0].Entries {
+			if _, ok := e.Message.(NextChildren); ok && e.From == v.Transcript.Matches[0].Prover {
+				n++
+			}
+		}
+		if n != rounds {
+			t.Errorf("depth %v: expected %v rounds, got %v", depth, rounds, n)
+		}
+		if winner, reason := v.Replay(v.Transcript.Matches[0]); winner != v.Transcript.Matches[0].Winner || reason != v.Transcript.Matches[0].Reason {
+			t.Errorf("depth %v: replay does not match the recorded result", depth)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	for dim, depth := range map[int]int{2: 17, 5: 7, 50: 3, 1 << 17: 1, 1 << 20: 1} {
+		if d := MaxDepth(dim); d != depth {
+			t.Errorf("dim %v: max depth %v instead of %v", dim, d, depth)
+		}
+		if d := openLevels(dim, 100, 100); d != depth {
+			t.Errorf("dim %v: opens %v levels when asked for 100 instead of %v", dim, d, depth)
+		}
+	}
+}
+
+func TestDepthPerMatch(t *testing.T) {
+	// the matches of one verifier may open different numbers of levels at once
+	v, stop := startSessions(5, generateTree(625, 5), generateTree(625, 5, 300))
+	defer stop()
+	v.ValidTransition = validTestTransition
+	v.Transcript = &Transcript{}
+	for _, c := range []struct{ depth, rounds int }{{1, 4}, {3, 2}, {0, 4}, {4, 1}} {
+		if winner := v.Match(0, 1, NewMountainRange(generateTree(625, 5, 300)), c.depth); winner != 0 {
+			t.Errorf("depth %v: fork wins the match", c.depth)
+		}
+		r := v.Transcript.Matches[len(v.Transcript.Matches)-1]
+		n := 0
+		for _, e := range r.Entries {
+			if _, ok := e.Message.(NextChildren); ok && e.From == r.Prover {
+				n++
+			}
+		}
+		if r.Depth != c.depth || n != c.rounds {
+			t.Errorf("depth %v: recorded depth %v and %v rounds instead of %v", c.depth, r.Depth, n, c.rounds)
+		}
+	}
+}
+
+func TestTranscriptReplay(t *testing.T) {
+	v, stop := startSessions(5,
+		generateTree(299, 5),
+		generateTree(273, 5, 100),
+		generateTree(280, 5),
+		generateTree(310, 5, 0),
+	)
+	v.Transcript = &Transcript{}
+	v.Run()
+	stop()
+	if len(v.Transcript.Matches) == 0 {
+		t.Fatal("no match is recorded")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := v.Transcript.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := ReadTranscript(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Matches) != len(v.Transcript.Matches) {
+		t.Fatal("incorrect number of matches after decoding")
+	}
+	for i, r := range tr.Matches {
+		winner, reason := v.Replay(r)
+		if winner != r.Winner || reason != r.Reason {
+			t.Errorf("match %v: recorded winner %v (%v), replayed winner %v (%v)", i, r.Winner, r.Reason, winner, reason)
+		}
+	}
+}
+
+func TestReplayTamperedTranscript(t *testing.T) {
+	v, stop := startSessions(5, generateTree(299, 5), generateTree(273, 5, 100))
+	v.Transcript = &Transcript{}
+	v.Run()
+	stop()
+	r := v.Transcript.Matches[0]
+	for i, e := range r.Entries {
+		if nc, ok := e.Message.(NextChildren); ok && e.From == r.Prover {
+			hashes := append([]Hash{}, nc.Hashes...)
+			hashes[0][0] ^= 1
+			r.Entries = append([]TranscriptEntry{}, r.Entries...)
+			r.Entries[i].Message = NextChildren{hashes}
+			break
+		}
+	}
+	winner, reason := v.Replay(r)
+	if winner != r.Challenger || reason != ReasonChildrenMismatch {
+		t.Errorf("tampered opening not caught: winner %v (%v)", winner, reason)
+	}
+}
+
+func TestSessionBadRequests(t *testing.T) {
+	tree := generateTreeLayout(10, 3, true)
+	i := make(chan Message, 100)
+	o := make(chan Message, 100)
+	s := &Session{Tree: tree, I: i, O: o}
+	go s.Run()
+	defer close(i)
+	exchange := func(msgs ...Message) Message {
+		for _, m := range msgs {
+			i <- m
+		}
+		var res Message
+		for range msgs {
+			res = <-o
+		}
+		return res
+	}
+	for n, req := range [][]Message{
+		{StartRoot{Index: 1}},
+		{StartRoot{Index: -1}},
+		{StartRoot{}, OpenNext{Index: 3}},
+		// the leaf at 18 is padding after the last leaf
+		{StartRoot{}, OpenNext{Index: 2}, OpenNext{Index: 0}, OpenNext{Index: 0}},
+		{NextChildren{}},
+		{nil},
+		{MountainRange{Roots: []Hash{{1}, {2}}, Sizes: []int{9}}},
+		{MountainRange{Roots: []Hash{{1}}, Sizes: []int{1 << 62}}},
+	} {
+		if res := exchange(req...); res != (Terminate{}) {
+			t.Errorf("request %v is answered with %#v", n, res)
+		}
+	}
+	if res := exchange(GetSuffix{Index: -3}); !reflect.DeepEqual(res, Suffix{}) {
+		t.Errorf("suffix at a negative index is %#v", res)
+	}
+	// the session goes on after the bad requests
+	if res := exchange(GetMountainRange{}); !reflect.DeepEqual(res, NewMountainRange(tree)) {
+		t.Errorf("mountain range is %#v", res)
+	}
+}
+
+func TestSilentServer(t *testing.T) {
+	v, stop := startSessions(5, generateTree(299, 5), generateTree(273, 5))
+	defer stop()
+	// a server that never answers
+	v.To = append(v.To, make(chan Message, 100))
+	v.From = append(v.From, make(chan Message))
+	v.Timeout = 50 * time.Millisecond
+	var results []MatchResult
+	v.ReportResult = func(r MatchResult) {
+		results = append(results, r)
+	}
+	for i := 0; i < 2; i++ {
+		results = nil
+		if _, winner, _ := v.Run(); winner != 0 {
+			t.Errorf("run %v: incorrect winner %v", i, winner)
+		}
+		timeouts := 0
+		for _, r := range results {
+			if r.Reason == ReasonTimeout {
+				timeouts += 1
+				if r.Prover != 2 || r.Winner != VerifierIndex {
+					t.Errorf("run %v: incorrect timeout result %+v", i, r)
+				}
+			}
+		}
+		// the silent server is not contacted again after it times out
+		if expected := 1 - i; timeouts != expected {
+			t.Errorf("run %v: %v timeouts reported, expecting %v", i, timeouts, expected)
+		}
+	}
+}
+
+func TestMatchStats(t *testing.T) {
+	for _, c := range []struct {
+		depth, rounds, hashes int
+	}{
+		// three openings and the leaf; a parent per opening, the leaf, the leaf
+		// before it with a proof of three levels, and the leaf of the challenger
+		// with a proof of three levels
+		{1, 4, 3 + 1 + 4 + 4},
+		// all levels at once, which hash to 25+5+1 parents
+		{3, 2, 31 + 1 + 4 + 4},
+	} {
+		v, stop := startSessions(5, generateTree(125, 5), generateTree(125, 5, 100))
+		v.Depth = c.depth
+		_, winner, stats := v.Run()
+		stop()
+		if len(stats) != 1 {
+			t.Fatalf("depth %v: expected stats of one match, got %v", c.depth, len(stats))
+		}
+		s := stats[0]
+		if s.Winner != winner || s.Reason != ReasonValidTransition {
+			t.Errorf("depth %v: stats report winner %v (%v), but %v wins", c.depth, s.Winner, s.Reason, winner)
+		}
+		if s.Rounds != c.rounds || s.Hashes != c.hashes {
+			t.Errorf("depth %v: expected %v rounds and %v hashes, got %v and %v", c.depth, c.rounds, c.hashes, s.Rounds, s.Hashes)
+		}
+		if s.BytesToChallenger == 0 || s.BytesFromChallenger == 0 || s.BytesToProver == 0 || s.BytesFromProver == 0 {
+			t.Errorf("depth %v: missing traffic in %+v", c.depth, s)
+		}
+	}
+}
+
+func TestNestedLedger(t *testing.T) {
+	// Match by itself asks the challenger for its mountain range to check the claim
+	honest, prefix := generateTree(299, 5), generateTree(150, 5)
+	v, stop := startSessions(5, honest, prefix)
+	var results []MatchResult
+	v.ReportResult = func(r MatchResult) {
+		results = append(results, r)
+	}
+	if winner := v.Match(0, 1, NewMountainRange(prefix), 0); winner != BothWin || results[0].Reason != ReasonNestedLedger {
+		t.Errorf("nested ledger not accepted: winner %v (%v)", winner, results[0].Reason)
+	}
+	stop()
+
+	liar := generateTree(320, 5, 100)
+	v, stop = startByzantine(5, FalseNested, liar, honest)
+	results = nil
+	v.ReportResult = func(r MatchResult) {
+		results = append(results, r)
+	}
+	if winner := v.Match(1, 0, NewMountainRange(honest), 0); winner != 0 || results[0].Reason != ReasonNestedProof {
+		t.Errorf("false nested claim not caught: winner %v (%v)", winner, results[0].Reason)
+	}
+	stop()
+}
+
+func TestDuplicateLeafGame(t *testing.T) {
+	// every leaf is the same except the one at the fork, which is invalid
+	data := func(diff int) MerkleTreeDataGenerator {
+		return func(i int) []byte {
+			if i == diff {
+				return []byte("fork")
+			}
+			return []byte("same")
+		}
+	}
+	valid := func(from, to, witness []byte) bool {
+		return string(to) == "same"
+	}
+	for _, stateless := range []bool{false, true} {
+		for _, depth := range []int{1, 2} {
+			honest := NewKVMerkleTree(NewInMemoryMerkleTreeStorage(), data(-1), 299, 5)
+			fork := NewKVMerkleTree(NewInMemoryMerkleTreeStorage(), data(100), 273, 5)
+			v, stop := startSessions(5, honest, fork)
+			v.ValidTransition = valid
+			v.Stateless = stateless
+			v.Depth = depth
+			var results []MatchResult
+			v.ReportResult = func(r MatchResult) {
+				results = append(results, r)
+			}
+			_, winner, _ := v.Run()
+			stop()
+			if winner != 0 || len(results) != 1 || results[0].Reason != ReasonInvalidTransition {
+				t.Errorf("stateless %v, depth %v: winner %v, results %+v", stateless, depth, winner, results)
+			}
+		}
+	}
+}
+
+func TestWeightedForkChoice(t *testing.T) {
+	data := func(diff int) MerkleTreeDataGenerator {
+		return func(i int) []byte {
+			if i == diff {
+				return []byte{byte(i), 1}
+			}
+			return []byte{byte(i)}
+		}
+	}
+	// leaves weigh 1, and the one where the fork starts weighs 1000
+	weight := func(data []byte) uint64 {
+		if len(data) > 1 {
+			return 1000
+		}
+		return 1
+	}
+	// a tree whose hashes claim that its leaves weigh nothing
+	lying := func(data []byte) uint64 {
+		return 0
+	}
+	build := func(n, diff int, mh MerkleHasher) MerkleTree {
+		return NewKVMerkleTreeWithHasher(NewInMemoryMerkleTreeStorage(), data(diff), n, 3, mh, false)
+	}
+	if w := NewMountainRange(build(60, 10, NewWeightedHasher(3, weight))).Weight(); w != 1059 {
+		t.Fatalf("ledger weighs %v instead of 1059", w)
+	}
+
+	// the larger ledger challenges the other one; by length, the prover wins with
+	// a valid transition, and by weight, both forks are valid and the heavier one
+	// wins
+	for _, weighted := range []bool{false, true} {
+		var mh MerkleHasher = NewSHA256Hasher(3)
+		challenger, reason := 0, ReasonValidTransition
+		if weighted {
+			mh = NewWeightedHasher(3, weight)
+			challenger, reason = 1, ReasonValidForks
+		}
+		v, stop := startSessions(3, build(100, -1, mh), build(60, 10, mh))
+		v.MerkleHasher = mh
+		_, winner, stats := v.Run()
+		stop()
+		if winner != 1 || len(stats) != 1 || stats[0].Challenger != challenger || stats[0].Reason != reason {
+			t.Errorf("weighted %v: winner %v, unexpected match %+v", weighted, winner, stats)
+		}
+	}
+
+	// a prover cannot hide the weight of its leaves
+	v, stop := startSessions(3, build(100, -1, NewWeightedHasher(3, weight)), build(50, -1, NewWeightedHasher(3, lying)))
+	v.MerkleHasher = NewWeightedHasher(3, weight)
+	var proofs []FraudProof
+	v.ReportFraud = func(prover int, fp FraudProof) {
+		proofs = append(proofs, fp)
+	}
+	_, winner, stats := v.Run()
+	stop()
+	if winner != 0 || len(stats) != 1 || stats[0].Reason != ReasonWeightMismatch {
+		t.Fatalf("lying prover is not caught: %+v", stats)
+	}
+	if len(proofs) != 1 || !VerifyFraudProof(NewWeightedHasher(3, weight), 3, nil, proofs[0]) {
+		t.Error("invalid fraud proof of the lying prover")
+	}
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Link emulates one direction of the network between a verifier and a server on
+// the channels they talk through. Every message is encoded with gob, as over TCP,
+// and delivered after the time to send its bytes at Bandwidth, plus Delay and a
+// random extra delay of up to Jitter. Messages are sent one at a time and
+// delivered in order, so they queue up behind each other on a slow link. A Link
+// may carry several channels, and counts the messages and bytes over all of them.
+type Link struct {
+	Delay     time.Duration
+	Jitter    time.Duration
+	Bandwidth int     // bytes per second, 0 for unlimited
+	Loss      float64 // probability that a message is dropped
+
+	messages int64
+	bytes    int64
+}
+
+type delivery struct {
+	msg Message
+	at  time.Time
+}
+
+// Run passes the messages from in through the link, and returns the channel they
+// come out of. The returned channel is closed after in is closed and the link has
+// delivered the messages in flight.
+func (l *Link) Run(in <-chan Message) <-chan Message {
+	out := make(chan Message, 100)
+	queue := make(chan delivery, 100)
+	go func() {
+		defer close(queue)
+		// the encoder sends the type of a message only once, so we keep one for
+		// the whole connection as writePeer does
+		buf := &bytes.Buffer{}
+		enc := gob.NewEncoder(buf)
+		dec := gob.NewDecoder(buf)
+		free := time.Now() // when the link is done sending the previous message
+		last := free       // when the previous message is delivered
+		for m := range in {
+			if err := enc.Encode(&m); err != nil {
+				panic(err)
+			}
+			n := buf.Len()
+			var d Message
+			if err := dec.Decode(&d); err != nil {
+				panic(err)
+			}
+			atomic.AddInt64(&l.messages, 1)
+			atomic.AddInt64(&l.bytes, int64(n))
+
+			if now := time.Now(); free.Before(now) {
+				free = now
+			}
+			if l.Bandwidth != 0 {
+				free = free.Add(time.Duration(n) * time.Second / time.Duration(l.Bandwidth))
+			}
+			if l.Loss > 0 && rand.Float64() < l.Loss {
+				// the message takes up the link, but never arrives
+				continue
+			}
+			at := free.Add(l.Delay)
+			if l.Jitter > 0 {
+				at = at.Add(time.Duration(rand.Int63n(int64(l.Jitter))))
+			}
+			if at.Before(last) {
+				at = last
+			}
+			last = at
+			queue <- delivery{d, at}
+		}
+	}()
+	go func() {
+		defer close(out)
+		for d := range queue {
+			time.Sleep(time.Until(d.at))
+			out <- d.msg
+		}
+	}()
+	return out
+}
+
+// Sent returns the number of messages and bytes sent over the link, including the
+// ones that are dropped.
+func (l *Link) Sent() (int64, int64) {
+	return atomic.LoadInt64(&l.messages), atomic.LoadInt64(&l.bytes)
+}
+
+// RunSession runs a Session over the tree behind the links, up for the messages
+// from the verifier to the session and down for the way back, and returns the
+// channels for the verifier. The session stops when the channel to it is closed.
+func RunSession(tree MerkleTree, up, down *Link) (chan<- Message, <-chan Message) {
+	to := make(chan Message, 100)
+	o := make(chan Message, 100)
+	s := &Session{
+		Tree: tree,
+		I:    up.Run(to),
+		O:    o,
+	}
+	go s.Run()
+	return to, down.Run(o)
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// startLinkedSessions is startSessions with every session behind a pair of links
+// with the given settings.
+func startLinkedSessions(dim int, l Link, trees ...MerkleTree) (*Verifier, []*Link) {
+	v := &Verifier{
+		Dim:          dim,
+		MerkleHasher: NewSHA256Hasher(dim),
+	}
+	var links []*Link
+	for _, tree := range trees {
+		up, down := l, l
+		to, from := RunSession(tree, &up, &down)
+		v.To = append(v.To, to)
+		v.From = append(v.From, from)
+		links = append(links, &up, &down)
+	}
+	return v, links
+}
+
+func TestLink(t *testing.T) {
+	honest := generateTree(299, 5)
+	fork := generateTreeLayout(320, 5, false, 100)
+	v, links := startLinkedSessions(5, Link{Delay: 5 * time.Millisecond, Jitter: 2 * time.Millisecond, Bandwidth: 1000000}, honest, fork)
+	v.ValidTransition = validTestTransition
+	start := time.Now()
+	_, winner, _ := v.Run()
+	elapsed := time.Since(start)
+	if winner != 0 {
+		t.Errorf("expected the honest server to win, got %v", winner)
+	}
+	// at least the mountain ranges and the root of the game go back and forth
+	if elapsed < 20*time.Millisecond {
+		t.Errorf("game over links with 5ms delay took only %v", elapsed)
+	}
+	for i, l := range links {
+		if msgs, bytes := l.Sent(); msgs == 0 || bytes == 0 {
+			t.Errorf("link %v counted %v messages and %v bytes", i, msgs, bytes)
+		}
+	}
+	for _, ch := range v.To {
+		close(ch)
+	}
+}
+
+func TestLossyLink(t *testing.T) {
+	honest := generateTree(299, 5)
+	v, links := startLinkedSessions(5, Link{Loss: 1}, honest, honest)
+	v.Timeout = 50 * time.Millisecond
+	if _, winner, _ := v.Run(); winner != NoWinner {
+		t.Errorf("expected no winner when every message is dropped, got %v", winner)
+	}
+	if msgs, _ := links[0].Sent(); msgs == 0 {
+		t.Errorf("dropped messages are not counted")
+	}
+	for _, ch := range v.To {
+		close(ch)
+	}
+}
@@ -3,22 +3,60 @@ package game
 import (
 	"crypto/sha256"
 	"github.com/akrylysov/pogreb"
-	"hash"
 	"encoding/binary"
-	"encoding"
 	"log"
+	"math"
 )
 
 type Hash [32]byte
 
+// MerkleTree is a mountain range of trees over the leaves of a ledger. Nodes are
+// addressed by their level (0 for leaves) and their position in the level rather
+// than by hash, as the ledger may hold the same data, and so the same subtrees,
+// more than once.
 type MerkleTree interface {
-	GetSubtreeSize(node Hash) int
+	GetDegree() int
+	NumLeaves() int
 	GetRoots() []Hash
-	GetChildren(node Hash) []Hash
-	GetProof(node Hash) []Hash
-	IsLeaf(node Hash) bool
-	GetData(node Hash) []byte
-	GetPrevSibling(node Hash) Hash // returns 0 if nonexistent
+	GetRootSizes() []int // number of leaves under each root
+	GetLeaf(idx int) Hash
+	GetData(idx int) []byte
+	// GetProof returns the children of every node on the path from leaf idx up to
+	// its root, from the bottom up.
+	GetProof(idx int) []Hash
+	// GetNode returns the node at the given level and position in the level of the
+	// tree over the first limit leaves, or zeroHash if the node has no leaves. The
+	// node does not need to be stored in the tree. Leaves after the end of the
+	// ledger are empty.
+	GetNode(level, pos, limit int) Hash
+}
+
+// capacity returns the number of leaves of the smallest perfect tree of degree
+// dim that holds size leaves.
+func capacity(dim, size int) int {
+	c := 1
+	for c < size {
+		c *= dim
+	}
+	return c
+}
+
+// height returns the level of the root of a perfect tree of degree dim with c
+// leaves.
+func height(dim, c int) int {
+	h := 0
+	for c > 1 {
+		c /= dim
+		h++
+	}
+	return h
+}
+
+// rootPosition returns the level and position of the root over size leaves from
+// start in a mountain range.
+func rootPosition(dim, start, size int) (int, int) {
+	c := capacity(dim, size)
+	return height(dim, c), start / c
 }
 
 type MerkleHasher interface {
@@ -27,43 +65,42 @@ type MerkleHasher interface {
 	CheckProof(leafData []byte, proof []Hash, roots ...Hash) bool
 }
 
+// SHA256Hasher is safe for concurrent use.
 type SHA256Hasher struct {
-	hasher hash.Hash
-	dim    int
+	dim int
 }
 
 func NewSHA256Hasher(dim int) *SHA256Hasher {
-	h := sha256.New()
-	return &SHA256Hasher{h, dim}
+	return &SHA256Hasher{dim}
 }
 
 func (h *SHA256Hasher) HashData(data []byte) Hash {
-	r := Hash{}
-	h.hasher.Reset()
-	h.hasher.Write(data[:])
-	h.hasher.Sum(r[:0])
-	return r
+	return sha256.Sum256(data)
 }
 
 func (h *SHA256Hasher) ComputeParent(children []Hash) Hash {
 	if len(children) != h.dim {
 		panic("incorrect dimension")
 	}
-	h.hasher.Reset()
+	hasher := sha256.New()
 	for _, c := range children {
-		h.hasher.Write(c[:])
+		hasher.Write(c[:])
 	}
 	var res Hash
-	h.hasher.Sum(res[:0])
+	hasher.Sum(res[:0])
 	return res
 }
 
 func (m *SHA256Hasher) CheckProof(leafData []byte, proof []Hash, roots ...Hash) bool {
+	return checkProof(m, m.dim, leafData, proof, roots)
+}
+
+func checkProof(m MerkleHasher, dim int, leafData []byte, proof []Hash, roots []Hash) bool {
 	leaf := m.HashData(leafData)
 	for len(proof) > 0 {
 		found := false
 		// look for leaf in the next level
-		for _, v := range proof[:m.dim] {
+		for _, v := range proof[:dim] {
 			if v == leaf {
 				found = true
 				break
@@ -72,8 +109,8 @@ func (m *SHA256Hasher) CheckProof(leafData []byte, proof []Hash, roots ...Hash)
 		if !found {
 			return false
 		}
-		leaf = m.ComputeParent(proof[:m.dim])
-		proof = proof[m.dim:]
+		leaf = m.ComputeParent(proof[:dim])
+		proof = proof[dim:]
 	}
 	for _, r := range roots {
 		if leaf == r {
@@ -83,63 +120,81 @@ func (m *SHA256Hasher) CheckProof(leafData []byte, proof []Hash, roots ...Hash)
 	return false
 }
 
-type kvMerkleTreeLeaf struct {
-	data  []byte
-	index int
+// WeightFunc returns the weight of a leaf from its data, e.g. the work of a block.
+type WeightFunc func(data []byte) uint64
+
+// WeightedHasher is a MerkleHasher whose nodes commit to the total weight of the
+// leaves under them. The last 8 bytes of a hash hold the weight, and the rest the
+// start of the SHA256 of the data or of the children. ComputeParent sums the
+// weights of the children, so every opening in the game checks the weights along
+// with the hashes. Empty subtrees weigh nothing. It is safe for concurrent use.
+type WeightedHasher struct {
+	dim    int
+	weight WeightFunc
 }
 
-func (k *kvMerkleTreeLeaf) UnmarshalBinary(data []byte) error {
-	k.index = int(binary.LittleEndian.Uint64(data[0:8]))
-	n := int(binary.LittleEndian.Uint64(data[8:16]))
-	k.data = make([]byte, n)
-	copy(k.data[:], data[16:16+n])
-	return nil
+func NewWeightedHasher(dim int, weight WeightFunc) *WeightedHasher {
+	return &WeightedHasher{dim, weight}
 }
 
-func (k *kvMerkleTreeLeaf) MarshalBinary() ([]byte, error) {
-	res := make([]byte, 16)
-	binary.LittleEndian.PutUint64(res[0:8], uint64(k.index))
-	binary.LittleEndian.PutUint64(res[8:16], uint64(len(k.data)))
-	res = append(res, k.data[:]...)
-	return res, nil
+// WeightOf returns the weight that a hash of a WeightedHasher commits to.
+func WeightOf(h Hash) uint64 {
+	return binary.BigEndian.Uint64(h[24:])
 }
 
-type kvMerkleTreeInternal struct {
-	children    []Hash
-	subtreeSize int
+func weighted(sum [32]byte, weight uint64) Hash {
+	var res Hash
+	copy(res[:24], sum[:24])
+	binary.BigEndian.PutUint64(res[24:], weight)
+	return res
 }
 
-func (k *kvMerkleTreeInternal) UnmarshalBinary(data []byte) error {
-	k.subtreeSize = int(binary.LittleEndian.Uint64(data[0:8]))
-	n := int(binary.LittleEndian.Uint64(data[8:16]))
-	k.children = make([]Hash, n)
-	for i := 0; i < n; i++ {
-		copy(k.children[i][:], data[16+32*i:16+32*i+32])
-	}
-	return nil
+func (h *WeightedHasher) HashData(data []byte) Hash {
+	return weighted(sha256.Sum256(data), h.weight(data))
 }
 
-func (k *kvMerkleTreeInternal) MarshalBinary() ([]byte, error) {
-	res := make([]byte, 16)
-	binary.LittleEndian.PutUint64(res[0:8], uint64(k.subtreeSize))
-	binary.LittleEndian.PutUint64(res[8:16], uint64(len(k.children)))
-	for _, v := range k.children {
-		res = append(res, v[:]...)
+func (h *WeightedHasher) ComputeParent(children []Hash) Hash {
+	if len(children) != h.dim {
+		panic("incorrect dimension")
+	}
+	hasher := sha256.New()
+	var weight uint64
+	for _, c := range children {
+		hasher.Write(c[:])
+		// saturate rather than wrap around, so that a ledger cannot look light by
+		// overflowing
+		if w := WeightOf(c); weight+w < weight {
+			weight = math.MaxUint64
+		} else {
+			weight += w
+		}
 	}
-	return res, nil
+	var sum [32]byte
+	hasher.Sum(sum[:0])
+	return weighted(sum, weight)
 }
 
+func (h *WeightedHasher) CheckProof(leafData []byte, proof []Hash, roots ...Hash) bool {
+	return checkProof(h, h.dim, leafData, proof, roots)
+}
+
+// KVMerkleTreeStorage stores the nodes of a mountain range by level and position,
+// the data of the leaves by index, and the number of leaves under each root. The
+// roots are in the order of the leaves, so their positions follow from the sizes.
+// Empty subtrees are not stored.
 type KVMerkleTreeStorage interface {
-	getLeaf(h Hash) (kvMerkleTreeLeaf, bool)
-	getLeafHashByIndex(idx int) Hash
-	getInternal(h Hash) (kvMerkleTreeInternal, bool)
-	getParent(h Hash) (Hash, bool)
-	getRoot(idx int) Hash
+	getNode(level, pos int) (Hash, bool)
+	storeNode(level, pos int, h Hash)
+	deleteNode(level, pos int)
+	getData(idx int) ([]byte, bool)
+	appendData(data []byte)
+	getNumLeaves() int
+	getRootSize(idx int) int
 	getNumRoots() int
-	appendLeaf(h Hash, l kvMerkleTreeLeaf)
-	storeInternal(h Hash, n kvMerkleTreeInternal)
-	storeParent(child Hash, parent Hash)
-	appendRoot(h Hash)
+	appendRoot(size int)
+	// truncate removes the data of the leaves from numLeaves on, and the sizes of
+	// the roots from numRoots on
+	truncate(numLeaves, numRoots int)
 }
 
 type DiskBackedMerkleTreeStorage interface {
@@ -183,98 +238,45 @@ func (s *PogrebMerkleTreeStorage) StoreDegree(d int) {
 	s.writeUint64(dimensionPrefix, uint64(d))
 }
 
-func (s *PogrebMerkleTreeStorage) readObjectByHash(prefix [8]byte, h Hash, ret encoding.BinaryUnmarshaler) bool {
-	key := [40]byte{}
-	copy(key[0:8], prefix[:])
-	copy(key[8:40], h[:])
-	val, err := s.db.Get(key[:])
-	if err != nil {
-		panic(err)
-	}
-	if val == nil {
-		return false
-	}
-	err = ret.UnmarshalBinary(val)
+func (s *PogrebMerkleTreeStorage) get(key []byte) []byte {
+	val, err := s.db.Get(key)
 	if err != nil {
 		panic(err)
 	}
-	return true
+	return val
 }
 
-func (s *PogrebMerkleTreeStorage) writeObjectByHash(prefix [8]byte, h Hash, v encoding.BinaryMarshaler) {
-	key := [40]byte{}
-	copy(key[0:8], prefix[:])
-	copy(key[8:40], h[:])
-	buf, err := v.MarshalBinary()
-	if err != nil {
-		panic(err)
-	}
-	err = s.db.Put(key[:], buf[:])
+func (s *PogrebMerkleTreeStorage) put(key []byte, val []byte) {
+	err := s.db.Put(key, val)
 	if err != nil {
 		panic(err)
 	}
-	return
-}
-
-func (s *PogrebMerkleTreeStorage) readHashByIndex(prefix [8]byte, idx uint64) (Hash, bool) {
-	key := [16]byte{}
-	copy(key[0:8], prefix[:])
-	binary.LittleEndian.PutUint64(key[8:], idx)
-	val, err := s.db.Get(key[:])
-	if err != nil {
-		panic(err)
-	}
-	if val == nil {
-		return Hash{}, false
-	}
-	var res Hash
-	copy(res[:], val[0:32])
-	return res, true
 }
 
-func (s *PogrebMerkleTreeStorage) writeHashByIndex(prefix [8]byte, idx uint64, v Hash) {
-	key := [16]byte{}
+func indexKey(prefix [8]byte, idx int) []byte {
+	key := make([]byte, 16)
 	copy(key[0:8], prefix[:])
-	binary.LittleEndian.PutUint64(key[8:], idx)
-	err := s.db.Put(key[:], v[:])
-	if err != nil {
-		panic(err)
-	}
-	return
+	binary.LittleEndian.PutUint64(key[8:16], uint64(idx))
+	return key
 }
 
-func (s *PogrebMerkleTreeStorage) readHashByHash(prefix [8]byte, h Hash) (Hash, bool) {
-	key := [40]byte{}
+func positionKey(prefix [8]byte, level, pos int) []byte {
+	key := make([]byte, 24)
 	copy(key[0:8], prefix[:])
-	copy(key[8:40], h[:])
-	val, err := s.db.Get(key[:])
-	if err != nil {
-		panic(err)
-	}
-	if val == nil {
-		return Hash{}, false
-	}
-	var res Hash
-	copy(res[:], val[0:32])
-	return res, true
+	binary.LittleEndian.PutUint64(key[8:16], uint64(level))
+	binary.LittleEndian.PutUint64(key[16:24], uint64(pos))
+	return key
 }
 
-func (s *PogrebMerkleTreeStorage) writeHashByHash(prefix [8]byte, k Hash, v Hash) {
-	key := [40]byte{}
-	copy(key[0:8], prefix[:])
-	copy(key[8:40], k[:])
-	err := s.db.Put(key[:], v[:])
+func (s *PogrebMerkleTreeStorage) delete(key []byte) {
+	err := s.db.Delete(key)
 	if err != nil {
 		panic(err)
 	}
-	return
 }
 
 func (s *PogrebMerkleTreeStorage) readUint64(key [8]byte) uint64 {
-	val, err := s.db.Get(key[:])
-	if err != nil {
-		panic(err)
-	}
+	val := s.get(key[:])
 	if val == nil {
 		return 0
 	}
@@ -284,125 +286,142 @@ func (s *PogrebMerkleTreeStorage) readUint64(key [8]byte) uint64 {
 func (s *PogrebMerkleTreeStorage) writeUint64(key [8]byte, d uint64) {
 	var buf [8]byte
 	binary.LittleEndian.PutUint64(buf[:], d)
-	err := s.db.Put(key[:], buf[:])
-	if err != nil {
-		panic(err)
-	}
-	return
+	s.put(key[:], buf[:])
 }
 
-var leafNodePrefix = [8]byte{1}
-var internalNodePrefix = [8]byte{2}
-var leafHashPrefix = [8]byte{3}
-var parentHashPrefix = [8]byte{4}
-var rootHashPrefix = [8]byte{5}
 var numberOfRootPrefix = [8]byte{6}
 var numberOfLeafPrefix = [8]byte{7}
 var dimensionPrefix = [8]byte{8}
+var nodeHashPrefix = [8]byte{9}
+var leafDataPrefix = [8]byte{10}
+var rootSizePrefix = [8]byte{11}
 
-func (s *PogrebMerkleTreeStorage) getLeaf(h Hash) (kvMerkleTreeLeaf, bool) {
-	var res kvMerkleTreeLeaf
-	ok := s.readObjectByHash(leafNodePrefix, h, &res)
-	return res, ok
-}
-
-func (s *PogrebMerkleTreeStorage) getLeafHashByIndex(idx int) Hash {
-	hash, ok := s.readHashByIndex(leafHashPrefix, uint64(idx))
-	if !ok {
-		panic("index does not exist")
+func (s *PogrebMerkleTreeStorage) getNode(level, pos int) (Hash, bool) {
+	val := s.get(positionKey(nodeHashPrefix, level, pos))
+	if val == nil {
+		return Hash{}, false
 	}
-	return hash
-}
-
-func (s *PogrebMerkleTreeStorage) getInternal(h Hash) (kvMerkleTreeInternal, bool) {
-	var res kvMerkleTreeInternal
-	ok := s.readObjectByHash(internalNodePrefix, h, &res)
-	return res, ok
+	var res Hash
+	copy(res[:], val[0:32])
+	return res, true
 }
 
-func (s *PogrebMerkleTreeStorage) getParent(h Hash) (Hash, bool) {
-	return s.readHashByHash(parentHashPrefix, h)
+func (s *PogrebMerkleTreeStorage) storeNode(level, pos int, h Hash) {
+	s.put(positionKey(nodeHashPrefix, level, pos), h[:])
 }
 
-func (s *PogrebMerkleTreeStorage) getRoot(idx int) Hash {
-	hash, ok := s.readHashByIndex(rootHashPrefix, uint64(idx))
-	if !ok {
-		panic("index does not exist")
-	}
-	return hash
+func (s *PogrebMerkleTreeStorage) deleteNode(level, pos int) {
+	s.delete(positionKey(nodeHashPrefix, level, pos))
 }
 
-func (s *PogrebMerkleTreeStorage) getNumRoots() int {
-	return int(s.readUint64(numberOfRootPrefix))
+func (s *PogrebMerkleTreeStorage) getData(idx int) ([]byte, bool) {
+	val := s.get(indexKey(leafDataPrefix, idx))
+	return val, val != nil
 }
 
-func (s *PogrebMerkleTreeStorage) appendLeaf(h Hash, l kvMerkleTreeLeaf) {
+func (s *PogrebMerkleTreeStorage) appendData(data []byte) {
 	idx := s.readUint64(numberOfLeafPrefix)
-	s.writeObjectByHash(leafNodePrefix, h, &l)
-	s.writeHashByIndex(leafHashPrefix, idx, h)
+	s.put(indexKey(leafDataPrefix, int(idx)), data)
 	s.writeUint64(numberOfLeafPrefix, idx+1)
-	return
 }
-func (s *PogrebMerkleTreeStorage) storeInternal(h Hash, n kvMerkleTreeInternal) {
-	s.writeObjectByHash(internalNodePrefix, h, &n)
-	return
+
+func (s *PogrebMerkleTreeStorage) getNumLeaves() int {
+	return int(s.readUint64(numberOfLeafPrefix))
 }
 
-func (s *PogrebMerkleTreeStorage) storeParent(child Hash, parent Hash) {
-	s.writeHashByHash(parentHashPrefix, child, parent)
-	return
+func (s *PogrebMerkleTreeStorage) getRootSize(idx int) int {
+	val := s.get(indexKey(rootSizePrefix, idx))
+	if val == nil {
+		panic("index does not exist")
+	}
+	return int(binary.LittleEndian.Uint64(val))
 }
 
-func (s *PogrebMerkleTreeStorage) appendRoot(h Hash) {
+func (s *PogrebMerkleTreeStorage) getNumRoots() int {
+	return int(s.readUint64(numberOfRootPrefix))
+}
+
+func (s *PogrebMerkleTreeStorage) appendRoot(size int) {
 	idx := s.readUint64(numberOfRootPrefix)
-	s.writeHashByIndex(rootHashPrefix, idx, h)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(size))
+	s.put(indexKey(rootSizePrefix, int(idx)), buf[:])
 	s.writeUint64(numberOfRootPrefix, idx+1)
-	return
 }
 
-// a read-only merkle tree stored in the memory
+func (s *PogrebMerkleTreeStorage) truncate(numLeaves, numRoots int) {
+	for idx := s.getNumLeaves() - 1; idx >= numLeaves; idx-- {
+		s.delete(indexKey(leafDataPrefix, idx))
+	}
+	s.writeUint64(numberOfLeafPrefix, uint64(numLeaves))
+	for idx := s.getNumRoots() - 1; idx >= numRoots; idx-- {
+		s.delete(indexKey(rootSizePrefix, idx))
+	}
+	s.writeUint64(numberOfRootPrefix, uint64(numRoots))
+}
+
+// a merkle tree stored in the memory, with the nodes of every level in a slice
 type InMemoryMerkleTreeStorage struct {
-	nodes  map[Hash]interface{}
-	parent map[Hash]Hash
-	leaves []Hash
-	roots  []Hash
+	levels [][]Hash // zeroHash where there is no node
+	data   [][]byte
+	roots  []int
 }
 
 func NewInMemoryMerkleTreeStorage() *InMemoryMerkleTreeStorage {
-	return &InMemoryMerkleTreeStorage {
-		nodes: make(map[Hash]interface{}),
-		parent: make(map[Hash]Hash),
+	return &InMemoryMerkleTreeStorage{}
+}
+
+func (s *InMemoryMerkleTreeStorage) getNode(level, pos int) (Hash, bool) {
+	if level >= len(s.levels) || pos >= len(s.levels[level]) {
+		return Hash{}, false
 	}
+	h := s.levels[level][pos]
+	return h, h != zeroHash
 }
 
-func (s *InMemoryMerkleTreeStorage) getLeaf(h Hash) (kvMerkleTreeLeaf, bool) {
-	switch n := s.nodes[h].(type) {
-	case kvMerkleTreeLeaf:
-		return n, true
-	default:
-		return kvMerkleTreeLeaf{}, false
+func (s *InMemoryMerkleTreeStorage) storeNode(level, pos int, h Hash) {
+	for len(s.levels) <= level {
+		s.levels = append(s.levels, nil)
+	}
+	for len(s.levels[level]) <= pos {
+		s.levels[level] = append(s.levels[level], zeroHash)
 	}
+	s.levels[level][pos] = h
 }
 
-func (s *InMemoryMerkleTreeStorage) getLeafHashByIndex(idx int) Hash {
-	return s.leaves[idx]
+func (s *InMemoryMerkleTreeStorage) deleteNode(level, pos int) {
+	if level >= len(s.levels) || pos >= len(s.levels[level]) {
+		return
+	}
+	s.levels[level][pos] = zeroHash
+	// drop the empty positions and levels at the end, so that the storage is the
+	// same as if the node had never been stored
+	l := s.levels[level]
+	for len(l) > 0 && l[len(l)-1] == zeroHash {
+		l = l[:len(l)-1]
+	}
+	s.levels[level] = l
+	for len(s.levels) > 0 && len(s.levels[len(s.levels)-1]) == 0 {
+		s.levels = s.levels[:len(s.levels)-1]
+	}
 }
 
-func (s *InMemoryMerkleTreeStorage) getInternal(h Hash) (kvMerkleTreeInternal, bool) {
-	switch n := s.nodes[h].(type) {
-	case kvMerkleTreeInternal:
-		return n, true
-	default:
-		return kvMerkleTreeInternal{}, false
+func (s *InMemoryMerkleTreeStorage) getData(idx int) ([]byte, bool) {
+	if idx >= len(s.data) {
+		return nil, false
 	}
+	return s.data[idx], true
+}
+
+func (s *InMemoryMerkleTreeStorage) appendData(data []byte) {
+	s.data = append(s.data, data)
 }
 
-func (s *InMemoryMerkleTreeStorage) getParent(h Hash) (Hash, bool) {
-	data, ok := s.parent[h]
-	return data, ok
+func (s *InMemoryMerkleTreeStorage) getNumLeaves() int {
+	return len(s.data)
 }
 
-func (s *InMemoryMerkleTreeStorage) getRoot(idx int) Hash {
+func (s *InMemoryMerkleTreeStorage) getRootSize(idx int) int {
 	return s.roots[idx]
 }
 
@@ -410,122 +429,304 @@ func (s *InMemoryMerkleTreeStorage) getNumRoots() int {
 	return len(s.roots)
 }
 
-func (s *InMemoryMerkleTreeStorage) appendLeaf(h Hash, l kvMerkleTreeLeaf) {
-	s.nodes[h] = l
-	s.leaves = append(s.leaves, h)
-	return
-}
-func (s *InMemoryMerkleTreeStorage) storeInternal(h Hash, n kvMerkleTreeInternal) {
-	s.nodes[h] = n
-	return
-}
-
-func (s *InMemoryMerkleTreeStorage) storeParent(child Hash, parent Hash) {
-	s.parent[child] = parent
-	return
+func (s *InMemoryMerkleTreeStorage) appendRoot(size int) {
+	s.roots = append(s.roots, size)
 }
 
-func (s *InMemoryMerkleTreeStorage) appendRoot(h Hash) {
-	s.roots = append(s.roots, h)
-	return
+func (s *InMemoryMerkleTreeStorage) truncate(numLeaves, numRoots int) {
+	s.data = s.data[:numLeaves]
+	s.roots = s.roots[:numRoots]
 }
 
+// KVMerkleTree is a mountain range of trees. Every root but the last one is a
+// perfect tree. The last root may be a partial tree, which is a perfect tree whose
+// leaves after the last one are empty; empty subtrees have zeroHash as hash.
 type KVMerkleTree struct {
 	KVMerkleTreeStorage
 	mh     MerkleHasher
+	dim    int
 }
 
-func (m *KVMerkleTree) GetSubtreeSize(node Hash) int {
-	n, ok := m.getInternal(node)
-	if ok {
-		return n.subtreeSize
-	}
-	_, ok = m.getLeaf(node)
-	if ok {
-		return 1
-	}
-	panic("unknown node")
+func (m *KVMerkleTree) NumLeaves() int {
+	return m.getNumLeaves()
 }
 
-func (m *KVMerkleTree) GetRoots() []Hash {
+func (m *KVMerkleTree) GetRootSizes() []int {
 	n := m.getNumRoots()
-	roots := []Hash{}
+	sizes := []int{}
 	for i := 0; i < n; i++ {
-		roots = append(roots, m.getRoot(i))
+		sizes = append(sizes, m.getRootSize(i))
 	}
-	return roots
+	return sizes
 }
 
-func (m *KVMerkleTree) GetChildren(node Hash) []Hash {
-	n, ok := m.getInternal(node)
-	if !ok {
-		panic("unknown node")
+func (m *KVMerkleTree) GetRoots() []Hash {
+	roots := []Hash{}
+	start := 0
+	for _, size := range m.GetRootSizes() {
+		level, pos := rootPosition(m.dim, start, size)
+		h, ok := m.getNode(level, pos)
+		if !ok {
+			panic("unknown node")
+		}
+		roots = append(roots, h)
+		start += size
 	}
-	return n.children
+	return roots
 }
 
-func (m *KVMerkleTree) GetProof(node Hash) []Hash {
-	_, yes := m.getLeaf(node)
-	if !yes {
-		panic("node is not a leaf")
+func (m *KVMerkleTree) GetProof(idx int) []Hash {
+	n := m.NumLeaves()
+	if idx < 0 || idx >= n {
+		panic("index does not exist")
 	}
-	proof := []Hash{}
-	for {
-		parent, there := m.getParent(node)
-		if !there {
+	// find the level of the root over the leaf
+	level, start := 0, 0
+	for _, size := range m.GetRootSizes() {
+		if idx < start+size {
+			level, _ = rootPosition(m.dim, start, size)
 			break
 		}
-		pn, ok := m.getInternal(parent)
-		if !ok {
-			panic("unknown node")
+		start += size
+	}
+	proof := []Hash{}
+	pos := idx
+	for l := 0; l < level; l++ {
+		first := pos / m.dim * m.dim
+		for i := 0; i < m.dim; i++ {
+			proof = append(proof, m.GetNode(l, first+i, n))
 		}
-		proof = append(proof, pn.children...)
-		node = parent
+		pos /= m.dim
 	}
 	return proof
 }
 
-func (m *KVMerkleTree) IsLeaf(node Hash) bool {
-	_, ok := m.getLeaf(node)
-	return ok
+func (m *KVMerkleTree) GetData(idx int) []byte {
+	data, ok := m.getData(idx)
+	if !ok {
+		panic("index does not exist")
+	}
+	return data
 }
 
-func (m *KVMerkleTree) GetData(node Hash) []byte {
-	n, ok := m.getLeaf(node)
-	if !ok {
-		panic("unknown node")
+func (m *KVMerkleTree) GetDegree() int {
+	return m.dim
+}
+
+func (m *KVMerkleTree) GetNode(level, pos, limit int) Hash {
+	c := 1
+	for i := 0; i < level; i++ {
+		c *= m.dim
+	}
+	start := pos * c
+	// leaves after the end of our ledger are empty
+	n := m.NumLeaves()
+	if limit > n {
+		limit = n
+	}
+	if start >= limit {
+		return zeroHash
+	}
+	// use the stored node if it has the same leaves, which are the ones up to the
+	// end of the ledger if the node is in the partial root
+	size, stored := c, c
+	if limit-start < size {
+		size = limit - start
+	}
+	if n-start < stored {
+		stored = n - start
 	}
-	return n.data
+	if node, ok := m.getNode(level, pos); ok && size == stored {
+		return node
+	}
+	if level == 0 {
+		panic("index does not exist")
+	}
+	children := make([]Hash, m.dim)
+	for i := range children {
+		children[i] = m.GetNode(level-1, pos*m.dim+i, limit)
+	}
+	return m.mh.ComputeParent(children)
 }
 
-func (m *KVMerkleTree) GetPrevSibling(node Hash) Hash {
-	n, ok := m.getLeaf(node)
+func (m *KVMerkleTree) GetLeaf(idx int) Hash {
+	h, ok := m.getNode(0, idx)
 	if !ok {
-		panic("unknown node")
-	}
-	if n.index > 0 {
-		return m.getLeafHashByIndex(n.index-1)
-	} else {
-		return Hash{}
+		panic("index does not exist")
 	}
+	return h
 }
 
 type MerkleTreeDataGenerator func(int) []byte
 
 func OpenKVMerkleTree(s DiskBackedMerkleTreeStorage) *KVMerkleTree {
+	return OpenKVMerkleTreeWithHasher(s, NewSHA256Hasher(s.GetDegree()))
+}
+
+// OpenKVMerkleTreeWithHasher is OpenKVMerkleTree for a tree built with the hasher
+// mh, e.g. a WeightedHasher.
+func OpenKVMerkleTreeWithHasher(s DiskBackedMerkleTreeStorage, mh MerkleHasher) *KVMerkleTree {
 	deg := s.GetDegree()
-	mh := NewSHA256Hasher(deg)
+	if _, ok := s.getNode(0, 0); !ok && s.getNumLeaves() != 0 {
+		panic("database does not store nodes by position; build it again")
+	}
 	return &KVMerkleTree {
 		KVMerkleTreeStorage: s,
 		mh:     mh,
+		dim:    deg,
 	}
 }
 
+// CopyTo copies the tree into the storage s, which should be empty, and returns
+// the tree over s. It may be used to load a tree on the disk into an
+// InMemoryMerkleTreeStorage, or to persist one that is in the memory.
+func (m *KVMerkleTree) CopyTo(s KVMerkleTreeStorage) *KVMerkleTree {
+	disk, isDisk := s.(DiskBackedMerkleTreeStorage)
+	if isDisk {
+		disk.StoreDegree(m.dim)
+	}
+	n := m.getNumLeaves()
+	for idx := 0; idx < n; idx++ {
+		data, ok := m.getData(idx)
+		if !ok {
+			panic("index does not exist")
+		}
+		s.appendData(data)
+		if (idx+1) % 1000000 == 0 {
+			log.Printf("copying leaves [%v/%v]\n", idx+1, n)
+			if isDisk {
+				disk.Commit()
+			}
+		}
+	}
+	// nodes are stored by position, so we go over the positions of every level up
+	// to the one of the highest root
+	top := 0
+	for i := 0; i < m.getNumRoots(); i++ {
+		size := m.getRootSize(i)
+		s.appendRoot(size)
+		if h := height(m.dim, capacity(m.dim, size)); h > top {
+			top = h
+		}
+	}
+	c := 1
+	for level := 0; level <= top; level++ {
+		for pos := 0; pos*c < n; pos++ {
+			if h, ok := m.getNode(level, pos); ok {
+				s.storeNode(level, pos, h)
+			}
+		}
+		c *= m.dim
+	}
+	return &KVMerkleTree{
+		KVMerkleTreeStorage: s,
+		mh:     m.mh,
+		dim:    m.dim,
+	}
+}
+
+// Truncate removes the leaves from n on, and leaves the tree as if it was built
+// over the first n leaves. The roots before the one that holds leaf n stay. The
+// leaves of that root which are before n get the roots that building the tree
+// would give them: perfect trees if the root was a perfect tree, or a single
+// partial tree if it was partial.
+func (m *KVMerkleTree) Truncate(n int) {
+	total := m.NumLeaves()
+	if n > total {
+		panic("cannot truncate to more leaves than the tree has")
+	}
+	if n == total {
+		return
+	}
+	// find the root that holds leaf n
+	sizes := m.GetRootSizes()
+	k, start := 0, 0
+	for start+sizes[k] <= n {
+		start += sizes[k]
+		k++
+	}
+	var newSizes []int
+	if rest := n - start; capacity(m.dim, sizes[k]) != sizes[k] {
+		if rest > 0 {
+			newSizes = append(newSizes, rest)
+		}
+	} else {
+		for rest > 0 {
+			size := 1
+			for size*m.dim <= rest {
+				size *= m.dim
+			}
+			newSizes = append(newSizes, size)
+			rest -= size
+		}
+	}
+
+	// nodes of a new partial root whose leaves go past n change, so we compute
+	// them while the leaves are still there
+	type node struct {
+		level, pos int
+		hash       Hash
+	}
+	var changed []node
+	s := start
+	for _, size := range newSizes {
+		c := m.dim
+		for level := 1; level <= height(m.dim, capacity(m.dim, size)); level++ {
+			for pos := s / c; pos*c < s+size; pos++ {
+				if pos*c+c > n {
+					changed = append(changed, node{level, pos, m.GetNode(level, pos, n)})
+				}
+			}
+			c *= m.dim
+		}
+		s += size
+	}
+
+	// remove the nodes of the old roots with leaves from n on
+	for i := k; i < len(sizes); i++ {
+		c := 1
+		for level := 0; level <= height(m.dim, capacity(m.dim, sizes[i])); level++ {
+			for pos := start / c; pos*c < start+sizes[i]; pos++ {
+				if pos*c+c > n {
+					m.deleteNode(level, pos)
+				}
+			}
+			c *= m.dim
+		}
+		start += sizes[i]
+	}
+	for _, nd := range changed {
+		m.storeNode(nd.level, nd.pos, nd.hash)
+	}
+	m.truncate(n, k)
+	for _, size := range newSizes {
+		m.appendRoot(size)
+	}
+}
+
+// NewKVMerkleTree builds a mountain range of perfect trees over n leaves.
 func NewKVMerkleTree(s KVMerkleTreeStorage, dg MerkleTreeDataGenerator, n int, dim int) *KVMerkleTree {
-	mh := NewSHA256Hasher(dim)
+	return buildKVMerkleTree(s, dg, n, dim, false)
+}
+
+// NewSingleRootKVMerkleTree builds a partial tree with a single root over n
+// leaves.
+func NewSingleRootKVMerkleTree(s KVMerkleTreeStorage, dg MerkleTreeDataGenerator, n int, dim int) *KVMerkleTree {
+	return buildKVMerkleTree(s, dg, n, dim, true)
+}
+
+func buildKVMerkleTree(s KVMerkleTreeStorage, dg MerkleTreeDataGenerator, n int, dim int, single bool) *KVMerkleTree {
+	return NewKVMerkleTreeWithHasher(s, dg, n, dim, NewSHA256Hasher(dim), single)
+}
+
+// NewKVMerkleTreeWithHasher builds the tree of NewKVMerkleTree, or of
+// NewSingleRootKVMerkleTree if single is set, with the hasher mh, e.g. a
+// WeightedHasher.
+func NewKVMerkleTreeWithHasher(s KVMerkleTreeStorage, dg MerkleTreeDataGenerator, n int, dim int, mh MerkleHasher, single bool) *KVMerkleTree {
 	m := &KVMerkleTree{
 		KVMerkleTreeStorage: s,
 		mh:     mh,
+		dim:    dim,
 	}
 
 	if disk, correct := m.KVMerkleTreeStorage.(DiskBackedMerkleTreeStorage); correct {
@@ -533,50 +734,50 @@ func NewKVMerkleTree(s KVMerkleTreeStorage, dg MerkleTreeDataGenerator, n int, d
 	}
 
 	idx := 0
+	total := n
 	for n > 0 {
 		// compute the size of the next tree
 		size := 1
 		for size*dim <= n {
 			size = size * dim
 		}
+		if single {
+			size = n
+		}
+		// position of the first node of the tree in the current level
+		level, pos := 0, idx
 		var nextHashes []Hash
 		for i := 0; i < size; i++ {
 			data := dg(idx)
-			l := kvMerkleTreeLeaf{
-				data:  data[:],
-				index: idx,
-			}
 			h := m.mh.HashData(data[:])
 			nextHashes = append(nextHashes, h)
-			m.appendLeaf(h, l)
+			m.appendData(data)
+			m.storeNode(0, idx, h)
 			idx++
 			if idx % 1000000 == 0 {
-				log.Printf("building dirty tree [%v/%v]\n", idx, n)
+				log.Printf("building dirty tree [%v/%v]\n", idx, total)
 				if disk, correct := m.KVMerkleTreeStorage.(DiskBackedMerkleTreeStorage); correct {
 					disk.Commit()
 				}
 			}
 		}
-		for len(nextHashes) > 1 {
-			var hashes []Hash // it is important that we allocate a new array because
-			// internal nodes are referencing into nextHashes
+		for c := capacity(dim, size); c > 1; c /= dim {
+			// pad the level of a partial tree with empty subtrees
+			for len(nextHashes)%dim != 0 {
+				nextHashes = append(nextHashes, zeroHash)
+			}
+			level += 1
+			pos /= dim
+			var hashes []Hash
 			nb := len(nextHashes) / dim
 			for i := 0; i < nb; i++ {
-				n := kvMerkleTreeInternal{
-					children:    nextHashes[i*dim : i*dim+dim],
-					subtreeSize: size / nb,
-				}
 				h := m.mh.ComputeParent(nextHashes[i*dim : i*dim+dim])
-				m.storeInternal(h, n)
+				m.storeNode(level, pos+i, h)
 				hashes = append(hashes, h)
-				for j := 0; j < dim; j++ {
-					m.storeParent(nextHashes[i*dim+j], h)
-				}
 			}
 			nextHashes = hashes
 		}
-		// append the root
-		m.appendRoot(nextHashes[0])
+		m.appendRoot(size)
 		n -= size
 	}
 	return m
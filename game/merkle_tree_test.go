@@ -1,13 +1,23 @@
 package game
 
 import (
+	"bytes"
 	"encoding/binary"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
 	"testing"
 	//"os"
-	//"path/filepath"
 )
 
 func generateTree(sz, dim int, diff ...int) *KVMerkleTree {
+	return generateTreeLayout(sz, dim, false, diff...)
+}
+
+// generateTreeLayout is generateTree, but builds a single partial tree if single is
+// set.
+func generateTreeLayout(sz, dim int, single bool, diff ...int) *KVMerkleTree {
 	diffSet := make(map[int]struct{})
 	for _, v := range diff {
 		diffSet[v] = struct{}{}
@@ -27,21 +37,22 @@ func generateTree(sz, dim int, diff ...int) *KVMerkleTree {
 	//}
 	//file := filepath.Join(dir, "db")
 	//storage := NewPogrebMerkleTreeStorage(file)
+	if single {
+		return NewSingleRootKVMerkleTree(storage, testData, sz, dim)
+	}
 	return NewKVMerkleTree(storage, testData, sz, dim)
 }
 
 func TestMerkleProof(t *testing.T) {
 	m := generateTree(125, 5)
-	p := m.GetProof(m.getLeafHashByIndex(40))
+	p := m.GetProof(40)
 	checker := NewSHA256Hasher(5)
 
-	n, _ := m.getLeaf(m.getLeafHashByIndex(40))
-	if !checker.CheckProof(n.data, p, m.getRoot(0)) {
+	if !checker.CheckProof(m.GetData(40), p, m.GetRoots()[0]) {
 		t.Error("proof does not pass check")
 	}
 	m = generateTree(125, 5, 40)
-	n, _ = m.getLeaf(m.getLeafHashByIndex(41))
-	if checker.CheckProof(n.data, p, m.getRoot(0)) {
+	if checker.CheckProof(m.GetData(41), p, m.GetRoots()[0]) {
 		t.Error("incorrect proof passes check")
 	}
 }
@@ -51,3 +62,258 @@ func TestCreateMerkleTree(t *testing.T) {
 	generateTree(0, 2)
 	generateTree(130, 2)
 }
+
+func TestPartialTreeProof(t *testing.T) {
+	m := generateTreeLayout(299, 5, true)
+	if len(m.GetRoots()) != 1 || m.GetRootSizes()[0] != 299 {
+		t.Fatal("partial tree does not have a single root over all leaves")
+	}
+	checker := NewSHA256Hasher(5)
+	for _, idx := range []int{0, 124, 250, 298} {
+		if !checker.CheckProof(m.GetData(idx), m.GetProof(idx), m.GetRoots()...) {
+			t.Errorf("proof of leaf %v does not pass check", idx)
+		}
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 100; round++ {
+		dim := 2 + rng.Intn(6)
+		n := 1 + rng.Intn(500)
+		limit := 1 + rng.Intn(n)
+		// the root of the partial tree over the prefix is the node of the same
+		// position over the first limit leaves of any longer tree
+		prefix := generateTreeLayout(limit, dim, true)
+		level := height(dim, capacity(dim, limit))
+		for _, single := range []bool{false, true} {
+			m := generateTreeLayout(n, dim, single)
+			if node := m.GetNode(level, 0, limit); node != prefix.GetRoots()[0] {
+				t.Errorf("dim %v, %v leaves, single %v: wrong node over the first %v leaves", dim, n, single, limit)
+			}
+		}
+		// the roots of the mountain range are nodes over all leaves
+		m := generateTree(n, dim)
+		start := 0
+		for i, r := range m.GetRoots() {
+			size := m.GetRootSizes()[i]
+			if node := m.GetNode(height(dim, size), start/size, n); node != r {
+				t.Errorf("dim %v, %v leaves: wrong node at root over leaves from %v", dim, n, start)
+			}
+			start += size
+		}
+	}
+}
+
+// checkPositionProof tells if the proof of leaf idx is the path from the position
+// of the leaf up to one of the roots, and not just a path from some leaf with the
+// same data.
+func checkPositionProof(dim int, data []byte, idx int, proof []Hash, roots []Hash) bool {
+	mh := NewSHA256Hasher(dim)
+	h := mh.HashData(data)
+	pos := idx
+	for len(proof) > 0 {
+		if len(proof) < dim || proof[pos%dim] != h {
+			return false
+		}
+		h = mh.ComputeParent(proof[:dim])
+		proof = proof[dim:]
+		pos /= dim
+	}
+	for _, r := range roots {
+		if h == r {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDuplicateLeaves(t *testing.T) {
+	// few distinct leaves, so that leaves and whole subtrees repeat
+	data := func(i int) []byte {
+		return []byte{byte(i % 3)}
+	}
+	for _, single := range []bool{false, true} {
+		m := buildKVMerkleTree(NewInMemoryMerkleTreeStorage(), data, 299, 3, single)
+		roots := m.GetRoots()
+		for idx := 0; idx < 299; idx++ {
+			if d := m.GetData(idx); len(d) != 1 || d[0] != byte(idx%3) {
+				t.Fatalf("single %v: wrong data at leaf %v", single, idx)
+			}
+			if !checkPositionProof(3, m.GetData(idx), idx, m.GetProof(idx), roots) {
+				t.Errorf("single %v: proof of leaf %v is not at its position", single, idx)
+			}
+			st := RevealTransition(m, idx)
+			if idx > 0 && (st.From[0] != byte((idx-1)%3) || !checkPositionProof(3, st.From, idx-1, st.FromProof, roots)) {
+				t.Errorf("single %v: transition to leaf %v opens the wrong leaf before it", single, idx)
+			}
+		}
+	}
+}
+
+func TestFlatFileStorage(t *testing.T) {
+	data := func(i int) []byte {
+		// leaves of different lengths, including empty ones
+		return make([]byte, i%5)
+	}
+	for _, single := range []bool{false, true} {
+		dir := t.TempDir()
+		s := NewFlatFileMerkleTreeStorage(dir)
+		m := buildKVMerkleTree(s, data, 299, 3, single)
+		expected := buildKVMerkleTree(NewInMemoryMerkleTreeStorage(), data, 299, 3, single)
+		check := func(m *KVMerkleTree) {
+			if !reflect.DeepEqual(m.GetRoots(), expected.GetRoots()) || !reflect.DeepEqual(m.GetRootSizes(), expected.GetRootSizes()) {
+				t.Fatalf("single %v: wrong roots", single)
+			}
+			for idx := 0; idx < 299; idx++ {
+				if !bytes.Equal(m.GetData(idx), expected.GetData(idx)) || !reflect.DeepEqual(m.GetProof(idx), expected.GetProof(idx)) {
+					t.Errorf("single %v: wrong leaf %v", single, idx)
+				}
+			}
+		}
+		// before and after the files are written
+		check(m)
+		s.Commit()
+		check(m)
+		s.Close()
+		s = NewFlatFileMerkleTreeStorage(dir)
+		check(OpenKVMerkleTree(s))
+		s.Close()
+	}
+}
+
+// benchmarkStorage measures proofs and openings of nodes in a tree of 4^8 leaves
+// kept in the storage, which is committed first if it is on disk.
+func benchmarkStorage(b *testing.B, s KVMerkleTreeStorage) {
+	const size, dim, height = 1 << 16, 4, 8
+	data := func(i int) []byte {
+		bs := make([]byte, 8)
+		binary.LittleEndian.PutUint64(bs, uint64(i))
+		return bs
+	}
+	m := NewKVMerkleTree(s, data, size, dim)
+	if d, ok := s.(DiskBackedMerkleTreeStorage); ok {
+		d.Commit()
+		defer d.Close()
+	}
+	rng := rand.New(rand.NewSource(1))
+	b.Run("GetProof", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m.GetProof(rng.Intn(size))
+		}
+	})
+	b.Run("Descendants", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			level := 1 + rng.Intn(height)
+			Descendants(m, level, rng.Intn(size>>(2*level)), 1)
+		}
+	})
+}
+
+func BenchmarkInMemoryStorage(b *testing.B) {
+	benchmarkStorage(b, NewInMemoryMerkleTreeStorage())
+}
+
+func BenchmarkPogrebStorage(b *testing.B) {
+	benchmarkStorage(b, NewPogrebMerkleTreeStorage(filepath.Join(b.TempDir(), "db")))
+}
+
+func BenchmarkFlatFileStorage(b *testing.B) {
+	benchmarkStorage(b, NewFlatFileMerkleTreeStorage(b.TempDir()))
+}
+
+func TestCopyTree(t *testing.T) {
+	data := func(i int) []byte {
+		return make([]byte, i%3)
+	}
+	for _, single := range []bool{false, true} {
+		mem := NewInMemoryMerkleTreeStorage()
+		expected := buildKVMerkleTree(mem, data, 350, 3, single)
+		dir := t.TempDir()
+		s := NewFlatFileMerkleTreeStorage(dir)
+		expected.CopyTo(s)
+		s.Commit()
+		s.Close()
+		s = NewFlatFileMerkleTreeStorage(dir)
+		loaded := NewInMemoryMerkleTreeStorage()
+		m := OpenKVMerkleTree(s).CopyTo(loaded)
+		s.Close()
+		if !reflect.DeepEqual(loaded, mem) {
+			t.Fatalf("single %v: storage changed in the round trip", single)
+		}
+		if !reflect.DeepEqual(m.GetRoots(), expected.GetRoots()) || m.GetDegree() != 3 {
+			t.Errorf("single %v: wrong roots", single)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	data := func(i int) []byte {
+		return make([]byte, i%4)
+	}
+	for _, single := range []bool{false, true} {
+		for _, n := range []int{0, 1, 26, 27, 28, 100, 161, 162, 199} {
+			mem := NewInMemoryMerkleTreeStorage()
+			m := buildKVMerkleTree(mem, data, 200, 3, single)
+			m.Truncate(n)
+			fresh := NewInMemoryMerkleTreeStorage()
+			buildKVMerkleTree(fresh, data, n, 3, single)
+			if n == 0 {
+				if len(mem.levels) != 0 || len(mem.data) != 0 || len(mem.roots) != 0 {
+					t.Errorf("single %v: tree is not empty", single)
+				}
+			} else if !reflect.DeepEqual(mem, fresh) {
+				t.Errorf("single %v: tree truncated to %v leaves is not the one built over them", single, n)
+			}
+
+			// the same on the disk, through a cache
+			dir := t.TempDir()
+			s := NewFlatFileMerkleTreeStorage(dir)
+			buildKVMerkleTree(s, data, 200, 3, single)
+			s.Commit()
+			OpenKVMerkleTree(NewCachedMerkleTreeStorage(s, 4096)).Truncate(n)
+			s.Commit()
+			s.Close()
+			s = NewFlatFileMerkleTreeStorage(dir)
+			loaded := NewInMemoryMerkleTreeStorage()
+			if n == 0 {
+				// an empty tree has no nodes, so it cannot be opened
+				if s.getNumLeaves() != 0 || s.getNumRoots() != 0 {
+					t.Errorf("single %v: tree on the disk is not empty", single)
+				}
+			} else if OpenKVMerkleTree(s).CopyTo(loaded); !reflect.DeepEqual(loaded, fresh) {
+				t.Errorf("single %v: tree on the disk truncated to %v leaves is not the one built over them", single, n)
+			}
+			s.Close()
+		}
+	}
+}
+
+func TestWeightedHasher(t *testing.T) {
+	data := func(i int) []byte {
+		return make([]byte, i%4)
+	}
+	weight := func(data []byte) uint64 {
+		return uint64(len(data))
+	}
+	mh := NewWeightedHasher(3, weight)
+	for _, single := range []bool{false, true} {
+		m := NewKVMerkleTreeWithHasher(NewInMemoryMerkleTreeStorage(), data, 100, 3, mh, single)
+		var total uint64
+		for idx := 0; idx < 100; idx++ {
+			total += weight(data(idx))
+			if !mh.CheckProof(m.GetData(idx), m.GetProof(idx), m.GetRoots()...) {
+				t.Errorf("single %v: invalid proof of leaf %v", single, idx)
+			}
+		}
+		if w := NewMountainRange(m).Weight(); w != total {
+			t.Errorf("single %v: ledger weighs %v instead of %v", single, w, total)
+		}
+	}
+	// weights saturate
+	heavy := mh.HashData(nil)
+	binary.BigEndian.PutUint64(heavy[24:], math.MaxUint64-1)
+	if w := WeightOf(mh.ComputeParent([]Hash{heavy, heavy, zeroHash})); w != math.MaxUint64 {
+		t.Errorf("weight overflows to %v", w)
+	}
+}
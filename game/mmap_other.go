@@ -0,0 +1,24 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package game
+
+import (
+	"os"
+)
+
+// mappingShared tells that writes to a file show up in its mapping. Without mmap,
+// the mapping is a copy of the file in memory.
+const mappingShared = false
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := f.ReadAt(b, 0); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func unmapFile(b []byte) error {
+	return nil
+}
@@ -1,23 +1,84 @@
 package game
 
+import (
+	"encoding/gob"
+	"math"
+)
+
+func init() {
+	// messages are sent as Message interfaces, so gob needs to know the types
+	gob.Register(OpenNext{})
+	gob.Register(StartRoot{})
+	gob.Register(OpenNode{})
+	gob.Register(NextChildren{})
+	gob.Register(StateTransition{})
+	gob.Register(MountainRange{})
+	gob.Register(GetMountainRange{})
+	gob.Register(NestedLedger{})
+	gob.Register(Terminate{})
+	gob.Register(GetSuffix{})
+	gob.Register(Suffix{})
+	gob.Register(GetTransition{})
+}
+
 var zeroHash = Hash{}
 
 type Message interface{}
 
-type GetMountainRange struct{}
+// GetMountainRange asks for the mountain range of a ledger. Ledger names the ledger
+// on a server that hosts several, see Session.Ledgers, and is empty for its
+// default ledger. The server plays over the ledger until it is asked for another.
+// OpenNode and GetSuffix name the ledger as well, as they may reach a server on
+// another connection.
+type GetMountainRange struct {
+	Ledger string
+}
 
-type NestedLedger struct{}
+// NestedLedger tells the verifier that the ledger of the prover is a prefix of the
+// ledger of the challenger. It proves the claim with the perfect subtrees of the
+// challenger before and after the last leaf of the prover, as in Suffix: the
+// verifier checks that all of them hash to the roots of the challenger, and the
+// ones before to the roots of the prover.
+type NestedLedger struct {
+	Prefix []Hash
+	Suffix []Hash
+}
 
 type Terminate struct{}
 
+// OpenNext asks the responder to open the child at Index. Hashes are the nodes of
+// the challenger at the positions of the descendants that the responder opened, of
+// which the one at Index must differ from the one of the responder. The challenger
+// proves them with its leaf at the end of the game, see GetTransition, and the
+// verifier does not pass them to the responder.
 type OpenNext struct {
-	Index int
+	Index  int
+	Hashes []Hash
+	Depth  int // see StartRoot
 }
 
+// StartRoot asks the responder to open its root at Index. Depth is the number of
+// levels to open at once, and the responder opens one level if it is zero. The
+// verifier sets it before passing the request to the responder.
 type StartRoot struct {
-	Index int
+	Index  int
+	Depth  int
+	Ledger string // see GetMountainRange
 }
 
+// OpenNode asks the responder to open its node at Level and Pos, which must have
+// the given hash. Unlike StartRoot and OpenNext, it does not depend on the previous
+// requests, so the responder does not keep any state across the game.
+type OpenNode struct {
+	Hash   Hash
+	Level  int
+	Pos    int
+	Depth  int    // see StartRoot
+	Ledger string // see GetMountainRange
+}
+
+// NextChildren holds the descendants of the opened node some levels below it,
+// usually one level, i.e. its children.
 type NextChildren struct {
 	Hashes []Hash
 }
@@ -26,6 +87,25 @@ type StateTransition struct {
 	From      []byte // from contains the prev state
 	FromProof []Hash
 	To        []byte // to contains the current state, and the tx that causes the transition
+	Witness   []byte // see WitnessTree; nil if the tree is not one
+	ToProof   []Hash // only in answers to GetTransition
+}
+
+// GetTransition asks the challenger, once the prover has opened a valid transition
+// at the disputed leaf, to open its own transition into the leaf at Index, with
+// the proofs of both leaves against its mountain range. The proof of the leaf
+// holds the nodes that the challenger opened along the game.
+type GetTransition struct {
+	Index  int
+	Ledger string // see GetMountainRange
+}
+
+// WitnessTree is a MerkleTree of a ledger whose transitions cannot be checked from
+// the two leaves alone. It sends a witness with every leaf it opens, e.g. the part
+// of the state that the transition into the leaf reads and writes.
+type WitnessTree interface {
+	MerkleTree
+	GetWitness(idx int) []byte
 }
 
 type MountainRange struct {
@@ -33,11 +113,43 @@ type MountainRange struct {
 	Sizes []int
 }
 
+// NumLeaves returns the number of leaves of the ledger.
+func (mr MountainRange) NumLeaves() int {
+	n := 0
+	for _, sz := range mr.Sizes {
+		n += sz
+	}
+	return n
+}
+
+// Weight returns the total weight of the ledger if the roots come from a
+// WeightedHasher, saturating at the largest uint64.
+func (mr MountainRange) Weight() uint64 {
+	var w uint64
+	for _, r := range mr.Roots {
+		if w+WeightOf(r) < w {
+			return math.MaxUint64
+		}
+		w += WeightOf(r)
+	}
+	return w
+}
+
 type Session struct {
 	Tree MerkleTree
-	I    <-chan Message
-	O    chan<- Message
-	ptr  Hash
+	// Ledgers, if not nil, are the other ledgers that the session can play over by
+	// name. Tree is the default one, whose name is empty.
+	Ledgers map[string]MerkleTree
+	I       <-chan Message
+	O       chan<- Message
+	ptr     Hash
+	initial MerkleTree // the default ledger once another one is selected
+
+	// the position of ptr: ptr is our node at the level and position of the
+	// disputed node, over the first limit leaves, which are our leaves when we are
+	// the responder, and the leaves of the prover up to the end of the disputed
+	// root when we are the challenger
+	level, pos, limit int
 }
 
 func (s *Session) Run() {
@@ -45,142 +157,316 @@ func (s *Session) Run() {
 	for msg := range s.I {
 		switch m := msg.(type) {
 		case GetMountainRange:
+			if !s.selectLedger(m.Ledger) {
+				// we do not host the ledger
+				s.O <- Terminate{}
+				continue
+			}
 			mr := s.mountainRange()
 			s.O <- mr
 		case MountainRange:
+			if !validMountainRange(s.Tree.GetDegree(), m) {
+				s.O <- Terminate{}
+				continue
+			}
 			s.runChallenger(m)
 		case StartRoot:
+			if !s.selectLedger(m.Ledger) {
+				s.O <- Terminate{}
+				continue
+			}
 			s.runResponder(m)
+		case OpenNode:
+			if s.selectLedger(m.Ledger) && HasNode(s.Tree, m.Hash, m.Level, m.Pos) {
+				s.O <- Open(s.Tree, m.Level, m.Pos, m.Depth)
+			} else {
+				// we cannot open a node we do not have
+				s.O <- Terminate{}
+			}
+		case GetSuffix:
+			if !s.selectLedger(m.Ledger) {
+				s.O <- Terminate{}
+				continue
+			}
+			s.O <- NewSuffix(s.Tree, m.Index)
+		case GetTransition:
+			if !s.selectLedger(m.Ledger) || m.Index < 0 || m.Index >= s.Tree.NumLeaves() {
+				s.O <- Terminate{}
+				continue
+			}
+			s.O <- ProveTransition(s.Tree, m.Index)
+		case Terminate:
+			// the game we were in has already ended
 		default:
-			panic("unexpected message type")
+			// the peer does not play by the protocol
+			s.O <- Terminate{}
 		}
 	}
 }
 
-func (s *Session) runResponder(sr StartRoot) {
-	s.ptr = s.Tree.GetRoots()[sr.Index]
+// selectLedger makes the named ledger the tree of the session, and tells if the
+// session has it.
+func (s *Session) selectLedger(name string) bool {
+	if s.initial == nil {
+		s.initial = s.Tree
+	}
+	if name == "" {
+		s.Tree = s.initial
+		return true
+	}
+	t, ok := s.Ledgers[name]
+	if ok {
+		s.Tree = t
+	}
+	return ok
+}
 
-	if s.Tree.IsLeaf(s.ptr) {
-		s.O <- s.revealTransition(s.ptr)
+func (s *Session) runResponder(sr StartRoot) {
+	if sr.Index < 0 || sr.Index >= len(s.Tree.GetRootSizes()) {
+		s.O <- Terminate{}
 		return
-	} else {
-		s.O <- NextChildren{s.Tree.GetChildren(s.ptr)}
 	}
-	for req := range s.I {
-		if _, terminate := req.(Terminate); terminate {
+	s.ptr = s.Tree.GetRoots()[sr.Index]
+	s.level, s.pos = s.rootAt(sr.Index)
+	s.limit = s.Tree.NumLeaves()
+	depth := sr.Depth
+	for s.level > 0 {
+		opened := Descendants(s.Tree, s.level, s.pos, depth)
+		s.O <- NextChildren{opened}
+		req, ok := <-s.I
+		if !ok {
 			return
 		}
-		if _, correct := req.(OpenNext); !correct {
-			panic("unexpected challenge type")
+		if _, terminate := req.(Terminate); terminate {
+			return
 		}
-		idx := req.(OpenNext).Index
-		s.ptr = s.Tree.GetChildren(s.ptr)[idx]
-		if s.Tree.IsLeaf(s.ptr) {
-			s.O <- s.revealTransition(s.ptr)
+		on, correct := req.(OpenNext)
+		if !correct || on.Index < 0 || on.Index >= len(opened) {
+			s.O <- Terminate{}
 			return
-		} else {
-			s.O <- NextChildren{s.Tree.GetChildren(s.ptr)}
 		}
+		s.descend(on.Index, opened[on.Index], openLevels(s.Tree.GetDegree(), depth, s.level))
+		depth = on.Depth
+	}
+	if s.pos >= s.Tree.NumLeaves() {
+		// the challenger picked the padding after our last leaf
+		s.O <- Terminate{}
+		return
 	}
+	s.O <- RevealTransition(s.Tree, s.pos)
+}
+
+// rootAt returns the level and position of our root at index i.
+func (s *Session) rootAt(i int) (int, int) {
+	sizes := s.Tree.GetRootSizes()
+	start := 0
+	for _, sz := range sizes[:i] {
+		start += sz
+	}
+	return rootPosition(s.Tree.GetDegree(), start, sizes[i])
 }
 
 func (s *Session) runChallenger(mr MountainRange) {
 	rt, needGame := s.setStartPtr(mr)
 	if !needGame {
 		// do not need a game
-		s.O <- NestedLedger{}
+		s.O <- s.nestedLedger(mr)
 		return
 	}
 	s.O <- rt
 
-	if s.Tree.IsLeaf(s.ptr) {
-		return
-	}
-	for resp := range s.I {
+	for s.level > 0 {
+		resp, ok := <-s.I
+		if !ok {
+			return
+		}
 		if _, terminate := resp.(Terminate); terminate {
 			return
 		}
-		// find the diff in the next level
-		if _, correct := resp.(NextChildren); !correct {
-			panic("unexpected response type")
+		// find the diff in the next level; the verifier checks the openings of the
+		// prover, so one that does not fit ends the game
+		nc, correct := resp.(NextChildren)
+		if !correct {
+			s.O <- Terminate{}
+			return
 		}
-
-		respHashes := resp.(NextChildren).Hashes
-		ourHashes := s.Tree.GetChildren(s.ptr)
+		respHashes := nc.Hashes
+		ourHashes, depth := s.descendants(len(respHashes))
 		if len(respHashes) != len(ourHashes) {
-			panic("incompatible dimensions of merkle trees")
+			s.O <- Terminate{}
+			return
 		}
 		found := false
 		for i := range ourHashes {
 			if ourHashes[i] != respHashes[i] {
-				// go downwards to the conflicting child
-				s.ptr = ourHashes[i]
-				s.O <- OpenNext{i}
+				s.descend(i, ourHashes[i], depth)
+				s.O <- OpenNext{Index: i, Hashes: ourHashes}
 				found = true
 				break
 			}
 		}
 		if !found {
-			panic("identical children in bisection game")
-		}
-		if s.Tree.IsLeaf(s.ptr) {
+			s.O <- Terminate{}
 			return
 		}
 	}
 }
 
+// descendants returns our nodes at the positions of the n descendants that the
+// responder opens below the disputed node, and the number of levels between them.
+// The responder opens the levels that the verifier asks for, so we take the
+// smallest depth with at least n descendants.
+func (s *Session) descendants(n int) ([]Hash, int) {
+	dim := s.Tree.GetDegree()
+	depth, width := 1, dim
+	for width < n && depth < s.level {
+		depth += 1
+		width *= dim
+	}
+	res := make([]Hash, width)
+	for i := range res {
+		res[i] = s.Tree.GetNode(s.level-depth, s.pos*width+i, s.limit)
+	}
+	return res, depth
+}
+
+// descend moves the disputed node to our descendant h at index i of the ones
+// depth levels below.
+func (s *Session) descend(i int, h Hash, depth int) {
+	width := 1
+	for j := 0; j < depth; j++ {
+		width *= s.Tree.GetDegree()
+	}
+	s.ptr = h
+	s.level -= depth
+	s.pos = s.pos*width + i
+}
+
+// nestedLedger proves that the ledger of the mountain range is nested in ours.
+func (s *Session) nestedLedger(mr MountainRange) NestedLedger {
+	n := 0
+	for _, sz := range mr.Sizes {
+		n += sz
+	}
+	return NestedLedger(NewSuffix(s.Tree, n))
+}
+
 func (s *Session) mountainRange() MountainRange {
-	r := MountainRange{
-		Roots: s.Tree.GetRoots(),
+	return NewMountainRange(s.Tree)
+}
+
+// NewMountainRange collects the roots of the tree and the sizes of their subtrees.
+func NewMountainRange(t MerkleTree) MountainRange {
+	return MountainRange{
+		Roots: t.GetRoots(),
+		Sizes: t.GetRootSizes(),
+	}
+}
+
+// RevealTransition opens the leaf at index idx of the tree together with the leaf
+// before it and the proof of the latter, and the witness of the transition if the
+// tree is a WitnessTree.
+func RevealTransition(t MerkleTree, idx int) StateTransition {
+	var st StateTransition
+	if idx > 0 {
+		st = StateTransition{From: t.GetData(idx - 1), FromProof: t.GetProof(idx - 1), To: t.GetData(idx)}
+	} else {
+		st = StateTransition{To: t.GetData(idx)}
 	}
-	for _, rt := range r.Roots {
-		r.Sizes = append(r.Sizes, s.Tree.GetSubtreeSize(rt))
+	if wt, ok := t.(WitnessTree); ok {
+		st.Witness = wt.GetWitness(idx)
 	}
-	return r
+	return st
 }
 
-func (s *Session) revealTransition(h Hash) StateTransition {
-	fh := s.Tree.GetPrevSibling(h)
-	if fh != zeroHash {
-		return StateTransition{s.Tree.GetData(fh), s.Tree.GetProof(fh), s.Tree.GetData(h)}
+// ProveTransition is RevealTransition with the proof of the leaf at idx, which the
+// challenger sends for its own leaf.
+func ProveTransition(t MerkleTree, idx int) StateTransition {
+	st := RevealTransition(t, idx)
+	st.ToProof = t.GetProof(idx)
+	return st
+}
+
+// HasNode tells if h is the node of the tree at the level and position.
+func HasNode(t MerkleTree, h Hash, level, pos int) bool {
+	n := t.NumLeaves()
+	if level < 0 || pos < 0 || pos >= n || level > height(t.GetDegree(), capacity(t.GetDegree(), n)) {
+		return false
+	}
+	return h != zeroHash && t.GetNode(level, pos, n) == h
+}
+
+// Open answers a bisection step on the node at the level and position without any
+// session state: it returns the StateTransition of the node if it is a leaf, and
+// the NextChildren of the node with the given depth otherwise.
+func Open(t MerkleTree, level, pos, depth int) Message {
+	if level == 0 {
+		return RevealTransition(t, pos)
 	} else {
-		return StateTransition{nil, nil, s.Tree.GetData(h)}
+		return NextChildren{Descendants(t, level, pos, depth)}
 	}
 }
 
-func (s *Session) setStartPtr(r MountainRange) (StartRoot, bool) {
-	roots := s.Tree.GetRoots()
-	theirIdx := 0
-	// look for the first root that is different
-	for theirIdx, s.ptr = range roots {
-		// compare subtree size
-		if s.Tree.GetSubtreeSize(s.ptr) == r.Sizes[theirIdx] {
-			// if subtree size is the same, then the root must be the same or we need
-			// to look into it
-			if s.ptr != r.Roots[theirIdx] {
-				return StartRoot{theirIdx}, true
-			}
-		} else {
-			// we found the first subtree with a different size
-			break
-		}
+// MaxOpenWidth is the largest number of nodes that a responder opens at once, so
+// that a peer cannot make it read and send a large part of the tree in one answer.
+const MaxOpenWidth = 1 << 17
+
+// MaxDepth returns the largest number of levels of a tree of degree dim that a
+// responder opens at once, which is at least one.
+func MaxDepth(dim int) int {
+	depth := 1
+	for width := dim * dim; dim > 1 && width <= MaxOpenWidth; width *= dim {
+		depth++
 	}
-	// scan until the end of peer's roots
-	childIdx := 0
-	for theirIdx < len(r.Roots) {
-		// go down until our children has the same size as peer's subtree
-		for s.Tree.GetSubtreeSize(s.Tree.GetChildren(s.ptr)[childIdx]) > r.Sizes[theirIdx] {
-			s.ptr = s.Tree.GetChildren(s.ptr)[childIdx]
-			childIdx = 0
-		}
-		if s.Tree.GetChildren(s.ptr)[childIdx] == r.Roots[theirIdx] {
-			// go to sibling
-			childIdx += 1
-		} else {
-			s.ptr = s.Tree.GetChildren(s.ptr)[childIdx]
-			return StartRoot{theirIdx}, true
+	return depth
+}
+
+// openLevels returns the number of levels to open below a node at the level of a
+// tree of degree dim when asked for depth levels.
+func openLevels(dim, depth, level int) int {
+	if depth < 1 {
+		depth = 1
+	}
+	if max := MaxDepth(dim); depth > max {
+		depth = max
+	}
+	if depth > level {
+		depth = level
+	}
+	return depth
+}
+
+// Descendants returns the nodes depth levels below the internal node at the level
+// and position, or the leaves under it if it has fewer levels. Empty subtrees have
+// empty descendants. It returns the children of the node if depth is zero.
+func Descendants(t MerkleTree, level, pos, depth int) []Hash {
+	d := openLevels(t.GetDegree(), depth, level)
+	width := 1
+	for i := 0; i < d; i++ {
+		width *= t.GetDegree()
+	}
+	n := t.NumLeaves()
+	nodes := make([]Hash, width)
+	for i := range nodes {
+		nodes[i] = t.GetNode(level-d, pos*width+i, n)
+	}
+	return nodes
+}
+
+// setStartPtr finds the first root of the peer that is different from our node at
+// the same position, and points ptr at our node.
+func (s *Session) setStartPtr(r MountainRange) (StartRoot, bool) {
+	dim := s.Tree.GetDegree()
+	start := 0
+	for i := range r.Roots {
+		s.level, s.pos = rootPosition(dim, start, r.Sizes[i])
+		s.limit = start + r.Sizes[i]
+		s.ptr = s.Tree.GetNode(s.level, s.pos, s.limit)
+		if s.ptr != r.Roots[i] {
+			return StartRoot{Index: i}, true
 		}
-		theirIdx += 1
+		start += r.Sizes[i]
 	}
-	return StartRoot{}, false // no need for bisection game
+	// all of peer's roots are the same as ours
+	return StartRoot{}, false
 }
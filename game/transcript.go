@@ -0,0 +1,142 @@
+package game
+
+import (
+	"encoding/gob"
+	"io"
+	"sync"
+	"time"
+)
+
+// VerifierIndex stands for the verifier in the From and To fields of a
+// TranscriptEntry, where other parties are denoted by their indices.
+const VerifierIndex = -2
+
+// Reason tells why a match ended the way it did.
+type Reason string
+
+const (
+	ReasonNestedLedger      Reason = "challenger reports the ledger of the prover as nested"
+	ReasonNestedProof       Reason = "challenger cannot prove that the ledger of the prover is nested"
+	ReasonChallengerMessage Reason = "unexpected message from the challenger"
+	ReasonProverMessage     Reason = "unexpected message from the prover"
+	ReasonChallengerIndex   Reason = "challenger picked a node that does not exist"
+	ReasonChallengerAgrees  Reason = "challenger picked a node it agrees with"
+	ReasonChallengerClaims  Reason = "challenger opened nodes that its mountain range does not commit to"
+	ReasonChildrenMismatch  Reason = "opened children do not hash to the parent"
+	ReasonNonEmptyPadding   Reason = "opened children after the last leaf are not empty"
+	ReasonLeafMismatch      Reason = "opened leaf does not hash to the disputed node"
+	ReasonWeightMismatch    Reason = "opened nodes do not add up to the weight of the disputed node"
+	ReasonPrevLeafProof     Reason = "invalid proof of the leaf before the disputed one"
+	ReasonPrevLeafAtZero    Reason = "nonempty leaf before the disputed one at index 0"
+	ReasonInvalidTransition Reason = "opened an invalid transition at the disputed leaf"
+	ReasonValidTransition   Reason = "prover opened a valid transition at the disputed leaf"
+	ReasonValidForks        Reason = "both parties opened valid transitions at the disputed leaf"
+	ReasonTimeout           Reason = "no answer in time"
+	ReasonMountainRange     Reason = "invalid mountain range"
+	ReasonUnsupportedLedger Reason = "server does not host the ledger"
+)
+
+// TranscriptEntry is a message sent or received by the verifier.
+type TranscriptEntry struct {
+	Time    time.Time
+	From    int
+	To      int
+	Message Message
+}
+
+// MatchRecord is the transcript of a match.
+type MatchRecord struct {
+	Start      time.Time
+	Challenger int
+	Prover     int
+	Range      MountainRange // the mountain range of the prover
+	// the mountain range of the challenger, if it claims that the ledger of the
+	// prover is nested in its own
+	ChallengerRange MountainRange
+	Depth           int // the number of levels opened in every round
+	Entries         []TranscriptEntry
+	Winner          int
+	Reason          Reason
+}
+
+// Transcript collects the records of the matches run by a verifier. It is safe
+// for concurrent use, so verifiers running in parallel may share it.
+type Transcript struct {
+	Matches []MatchRecord
+	lock    sync.Mutex
+}
+
+func (t *Transcript) add(r MatchRecord) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.Matches = append(t.Matches, r)
+}
+
+// Write encodes the transcript with gob.
+func (t *Transcript) Write(w io.Writer) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return gob.NewEncoder(w).Encode(t)
+}
+
+// ReadTranscript decodes a transcript written by Transcript.Write.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	if err := gob.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Replay runs the match logic of the verifier again over the messages that the
+// challenger and the prover sent in a recorded match, and returns the winner and
+// the reason. The verifier must have the same Dim as the one that recorded it.
+func (v *Verifier) Replay(r MatchRecord) (int, Reason) {
+	winner, reason, _ := v.replay(r)
+	return winner, reason
+}
+
+// replay runs the match as Replay does, and also tells if the match reads all the
+// messages the parties sent.
+func (v *Verifier) replay(r MatchRecord) (int, Reason, bool) {
+	n := r.Challenger + 1
+	if r.Prover >= n {
+		n = r.Prover + 1
+	}
+	to := make([]chan<- Message, n)
+	from := make([]<-chan Message, n)
+	for _, party := range []int{r.Challenger, r.Prover} {
+		var in []Message
+		for _, e := range r.Entries {
+			if e.From == party {
+				in = append(in, e.Message)
+			}
+		}
+		// the channels are large enough for the match to never block; a match
+		// that asks for more messages than recorded reads nil and ends
+		f := make(chan Message, len(in))
+		for _, msg := range in {
+			f <- msg
+		}
+		close(f)
+		from[party] = f
+		to[party] = make(chan Message, len(r.Entries)+3)
+	}
+	rv := &Verifier{
+		To:              to,
+		From:            from,
+		Dim:             v.Dim,
+		MerkleHasher:    v.MerkleHasher,
+		ValidTransition: v.ValidTransition,
+		Stateless:       v.Stateless,
+	}
+	var ranges []MountainRange
+	if len(r.ChallengerRange.Roots) != 0 {
+		ranges = make([]MountainRange, r.Challenger+1)
+		ranges[r.Challenger] = r.ChallengerRange
+	}
+	m := newMatch(rv, ranges, r.Challenger, r.Prover, r.Depth)
+	winner, reason := m.play(r.Range)
+	consumed := len(from[r.Challenger]) == 0 && len(from[r.Prover]) == 0
+	return winner, reason, consumed
+}
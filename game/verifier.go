@@ -1,70 +1,434 @@
 package game
 
+import (
+	"encoding/gob"
+	"sync"
+	"time"
+)
+
 // Verifier implements the light client.
 type Verifier struct {
 	To   []chan<- Message
 	From []<-chan Message
 
 	Dim int
+	// MerkleHasher may be a WeightedHasher, which makes the verifier pick the
+	// ledger of the largest weight rather than the one with the most leaves, and
+	// check the weights that the prover opens.
 	MerkleHasher
+
+	// ValidTransition, if not nil, checks the transition from the leaf before the
+	// disputed one to the disputed leaf with the witness that the prover sends
+	// along, which is nil unless its tree is a WitnessTree. From is nil if the
+	// disputed leaf is the first one. A prover that opens an invalid transition
+	// loses.
+	ValidTransition func(from, to, witness []byte) bool
+
+	// Parallel makes Run play matches between disjoint pairs of servers in parallel
+	// instead of one at a time. The Report callbacks are still called one at a
+	// time.
+	Parallel bool
+
+	// Depth is the number of levels the prover opens at once in every round of the
+	// matches that Run plays, one if it is zero. The prover sends Dim^Depth hashes
+	// per round, so a larger Depth trades bandwidth for fewer round trips. Match
+	// takes the depth of each match by itself.
+	Depth int
+
+	// Ledger names the ledger to contest on servers that host several, and is
+	// empty for their default ledger. Run moves every server to the ledger when it
+	// asks for the mountain ranges. See GetMountainRange.
+	Ledger string
+
+	// Stateless makes the verifier name the node to open in every request to the
+	// prover, so that the prover does not need to keep state during the game.
+	Stateless bool
+
+	// Transcript, if not nil, records the messages exchanged in every match.
+	Transcript *Transcript
+
+	// ReportFraud, if not nil, is called with the index of the prover and the
+	// fraud proof whenever a prover loses a match by an invalid opening.
+	ReportFraud func(prover int, fp FraudProof)
+
+	// ReportResult, if not nil, is called with the result of every match. A server
+	// that fails to send a valid mountain range in Run is reported as a prover that
+	// loses to the verifier, i.e. with VerifierIndex as the challenger and winner,
+	// except that a server which does not host the ledger is reported with
+	// ReasonUnsupportedLedger and NoWinner, as it is not at fault.
+	ReportResult func(r MatchResult)
+
+	// Diff makes the verifier look for the extent of the fork after the game of
+	// every match that Run plays, and call ReportDiff with the result.
+	Diff       bool
+	ReportDiff func(r DiffReport)
+
+	// Timeout, if nonzero, is how long the verifier waits for a message from a
+	// server before the server loses. A server that times out may still answer
+	// later, so the verifier stops talking to it afterwards.
+	Timeout time.Duration
+	stalled map[int]struct{}
+	lock    sync.Mutex
+
+	reportLock sync.Mutex // held while calling the Report callbacks
+}
+
+// MatchResult is the outcome of a match.
+type MatchResult struct {
+	Challenger int
+	Prover     int
+	Winner     int
+	Reason     Reason
+}
+
+// MatchStats is the cost of a match next to its outcome. Bytes are counted as gob
+// encodes the messages on a connection, whatever carries them.
+type MatchStats struct {
+	MatchResult
+	Rounds              int // openings of the prover, including the leaf
+	BytesToChallenger   int
+	BytesFromChallenger int
+	BytesToProver       int
+	BytesFromProver     int
+	Hashes              int // hashes computed by the verifier
+	Duration            time.Duration
 }
 
 const (
 	BothWin = -1
+	// NoWinner is returned by Run when no server sends a valid mountain range.
+	NoWinner = -3
 )
 
 // Match runs a match between a challenger and a prover. It takes the indices of the
 // two parties, and the mountain range reported by the prover, which should have a
-// shorter ledger than the challenger. It returns the index of the winner.
-func (v *Verifier) Match(cidx, pidx int, pmr MountainRange) int {
+// shorter ledger than the challenger, and the number of levels the prover opens in
+// every round, as Depth. It returns the index of the winner, or BothWin if neither
+// party is caught, as when the ledger of the prover is nested in the one of the
+// challenger, or both are valid forks of a weighted ledger. The challenger first
+// sends its mountain range of the ledger, which it is bound to in the game, and
+// loses if it does not.
+func (v *Verifier) Match(cidx, pidx int, pmr MountainRange, depth int) int {
+	cmr, reason := v.fetchMountainRange(cidx)
+	if reason != "" {
+		if v.ReportResult != nil {
+			v.reportLock.Lock()
+			v.ReportResult(MatchResult{cidx, pidx, pidx, reason})
+			v.reportLock.Unlock()
+		}
+		return pidx
+	}
+	ranges := make([]MountainRange, cidx+1)
+	ranges[cidx] = cmr
+	winner, _ := v.runMatch(ranges, cidx, pidx, pmr, depth)
+	return winner
+}
+
+// runMatch is Match that also returns the statistics of the match. Ranges are the
+// mountain ranges that Run has fetched from the servers, if any.
+func (v *Verifier) runMatch(ranges []MountainRange, cidx, pidx int, pmr MountainRange, depth int) (int, MatchStats) {
+	m := newMatch(v, ranges, cidx, pidx, depth)
+	m.record = v.Transcript != nil
+	start := time.Now()
+	winner, reason := m.play(pmr)
+	if !m.finished {
+		// the match ended early, and the parties may still be waiting for the game
+		// to continue
+		m.send(cidx, Terminate{})
+		m.send(pidx, Terminate{})
+	}
+	if m.timeout {
+		reason = ReasonTimeout
+	} else if v.Diff && m.finished && cidx < len(ranges) {
+		firstDiff := m.diffIdx
+		if reason == ReasonNestedLedger {
+			firstDiff = -1
+		}
+		if r, ok := m.diff(ranges[cidx], pmr, firstDiff); ok && v.ReportDiff != nil {
+			v.reportLock.Lock()
+			v.ReportDiff(r)
+			v.reportLock.Unlock()
+		}
+	}
+	v.reportLock.Lock()
+	if v.ReportResult != nil {
+		v.ReportResult(MatchResult{cidx, pidx, winner, reason})
+	}
+	if v.ReportFraud != nil && winner == cidx && reason.IsFraud() {
+		v.ReportFraud(pidx, m.fraudProof(pmr, reason))
+	}
+	v.reportLock.Unlock()
+	if m.record {
+		v.Transcript.add(MatchRecord{
+			Start:           start,
+			Challenger:      cidx,
+			Prover:          pidx,
+			Range:           pmr,
+			ChallengerRange: m.crange,
+			Depth:           m.depth,
+			Entries:         m.entries,
+			Winner:          winner,
+			Reason:          reason,
+		})
+	}
+	stats := MatchStats{
+		MatchResult:         MatchResult{cidx, pidx, winner, reason},
+		Rounds:              len(m.openings),
+		BytesToChallenger:   m.bytes(VerifierIndex, cidx),
+		BytesFromChallenger: m.bytes(cidx, VerifierIndex),
+		BytesToProver:       m.bytes(VerifierIndex, pidx),
+		BytesFromProver:     m.bytes(pidx, VerifierIndex),
+		Hashes:              m.hasher.n,
+		Duration:            time.Since(start),
+	}
+	if m.leaf != nil {
+		stats.Rounds += 1
+	}
+	return winner, stats
+}
+
+// match holds the state of one match while it is being played.
+type match struct {
+	*Verifier
+	ranges     []MountainRange // of the servers, if Run has fetched them
+	cidx, pidx int
+	depth      int // see Depth
+	record     bool
+	entries    []TranscriptEntry
+	timeout    bool
+	finished   bool // both parties have left the game by themselves
+	diffIdx    int  // index of the disputed leaf, once the game reaches it
+	hasher     *countingHasher
+	traffic    map[[2]int]*countingEncoder // by sender and receiver
+	crange     MountainRange               // of the challenger, if the match looked at it
+
+	// the openings of the prover, for constructing fraud proofs
+	start    int
+	openings [][]Hash
+	choices  []int
+	leaf     *StateTransition
+
+	// the openings of the challenger, which it proves at the end of the game, and
+	// the end of the root of the prover that the game is over
+	claims []claim
+	limit  int
+}
+
+// claim is the nodes that the challenger says it has at the positions of the
+// descendants that the prover opens in a round: from position base at the level.
+type claim struct {
+	level, base int
+	hashes      []Hash
+}
+
+func newMatch(v *Verifier, ranges []MountainRange, cidx, pidx, depth int) *match {
+	return &match{
+		Verifier: v,
+		ranges:   ranges,
+		cidx:     cidx,
+		pidx:     pidx,
+		depth:    depth,
+		hasher:   &countingHasher{MerkleHasher: v.MerkleHasher, dim: v.Dim},
+		traffic:  make(map[[2]int]*countingEncoder),
+	}
+}
+
+// countingHasher counts the hashes computed by the MerkleHasher it wraps.
+type countingHasher struct {
+	MerkleHasher
+	dim int
+	n   int
+}
+
+func (h *countingHasher) HashData(data []byte) Hash {
+	h.n += 1
+	return h.MerkleHasher.HashData(data)
+}
+
+func (h *countingHasher) ComputeParent(children []Hash) Hash {
+	h.n += 1
+	return h.MerkleHasher.ComputeParent(children)
+}
+
+func (h *countingHasher) CheckProof(leafData []byte, proof []Hash, roots ...Hash) bool {
+	// the leaf, and one parent per level of the proof
+	h.n += 1 + len(proof)/h.dim
+	return h.MerkleHasher.CheckProof(leafData, proof, roots...)
+}
+
+// countingEncoder encodes messages as writePeer does, and counts the bytes. The
+// encoder sends the type of a message only once, so we keep one per direction.
+type countingEncoder struct {
+	enc *gob.Encoder
+	n   int
+}
+
+func (c *countingEncoder) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
+
+func (m *match) count(from, to int, msg Message) {
+	c, ok := m.traffic[[2]int{from, to}]
+	if !ok {
+		c = &countingEncoder{}
+		c.enc = gob.NewEncoder(c)
+		m.traffic[[2]int{from, to}] = c
+	}
+	// a message that gob cannot encode is counted as empty
+	c.enc.Encode(&msg)
+}
+
+func (m *match) bytes(from, to int) int {
+	if c, ok := m.traffic[[2]int{from, to}]; ok {
+		return c.n
+	}
+	return 0
+}
+
+func (m *match) send(to int, msg Message) {
+	m.count(VerifierIndex, to, msg)
+	if m.record {
+		m.entries = append(m.entries, TranscriptEntry{time.Now(), VerifierIndex, to, msg})
+	}
+	m.To[to] <- msg
+}
+
+func (m *match) recv(from int) Message {
+	msg, ok := m.receive(from)
+	if !ok {
+		// the match ends as the message has the wrong type
+		m.timeout = true
+	} else {
+		m.count(from, VerifierIndex, msg)
+	}
+	if m.record {
+		m.entries = append(m.entries, TranscriptEntry{time.Now(), from, VerifierIndex, msg})
+	}
+	return msg
+}
+
+// play runs the match, and returns the index of the winner and why the loser lost.
+func (m *match) play(pmr MountainRange) (int, Reason) {
 	var diffIdx int
 	var responderPtr Hash
 	var responderSize int
+	cidx, pidx := m.cidx, m.pidx
 
 	// send the mountain range to the challenger, and wait for it to pick the start point
 	// for the game
-	v.To[cidx] <- pmr
+	m.send(cidx, pmr)
 	var sr StartRoot
-	switch m := (<-v.From[cidx]).(type) {
+	switch msg := m.recv(cidx).(type) {
 	case StartRoot:
-		sr = m
+		sr = msg
 	case NestedLedger:
-		return BothWin
+		if !m.checkNested(pmr, msg) {
+			return pidx, ReasonNestedProof
+		}
+		m.finished = true
+		return BothWin, ReasonNestedLedger
 	default:
-		return pidx	// unexpected type from the challenger
+		return pidx, ReasonChallengerMessage // unexpected type from the challenger
 	}
 
-	v.To[pidx] <- sr
+	if sr.Index < 0 || sr.Index >= len(pmr.Roots) {
+		return pidx, ReasonChallengerIndex
+	}
+	if cmr, ok := m.committedRange(); ok && sameRoot(cmr, pmr, sr.Index) {
+		// the challenger starts the game at a root it agrees with
+		return pidx, ReasonChallengerAgrees
+	}
+	m.start = sr.Index
 	responderPtr = pmr.Roots[sr.Index]
 	responderSize = pmr.Sizes[sr.Index]
+	// the root may be a partial tree, whose leaves after responderSize are empty
+	responderCap := capacity(m.Dim, responderSize)
+	// the position of the disputed node, which the stateless prover needs
+	start := 0
+	for _, sz := range pmr.Sizes[:sr.Index] {
+		start += sz
+	}
+	level, pos := rootPosition(m.Dim, start, responderSize)
+	m.limit = start + responderSize
+	depth := m.depth
+	if depth < 1 {
+		depth = 1
+	}
+	// the responder opens no more than MaxDepth levels, whatever we ask for
+	if max := MaxDepth(m.Dim); depth > max {
+		depth = max
+	}
+	if m.Stateless {
+		m.send(pidx, OpenNode{responderPtr, level, pos, depth, m.Ledger})
+	} else {
+		sr.Depth = depth
+		sr.Ledger = m.Ledger
+		m.send(pidx, sr)
+	}
 
 	// run the bisection game to find the first disargeement
-	for responderSize > 1 {
+	for responderCap > 1 {
+		// the responder opens depth levels, or the rest of the levels if there
+		// are fewer
+		n := 1
+		for i := 0; i < depth && n < responderCap; i++ {
+			n *= m.Dim
+		}
 		// wait for the opening from the responder
-		nc, ok := (<-v.From[pidx]).(NextChildren)
+		nc, ok := m.recv(pidx).(NextChildren)
 		if !ok {
-			return cidx
+			return cidx, ReasonProverMessage
+		}
+		m.openings = append(m.openings, nc.Hashes)
+		if len(nc.Hashes) != n {
+			return cidx, ReasonChildrenMismatch
 		}
-		if v.MerkleHasher.ComputeParent(nc.Hashes) != responderPtr {
+		if anc := m.ancestor(nc.Hashes); anc != responderPtr {
+			if m.weighted() && WeightOf(anc) != WeightOf(responderPtr) {
+				return cidx, ReasonWeightMismatch
+			}
 			// responder loses because the opening does not match the parent hash
-			return cidx
+			return cidx, ReasonChildrenMismatch
 		}
-		v.To[cidx] <- nc
+		responderCap /= n
+		for i := range nc.Hashes {
+			if i*responderCap >= responderSize && nc.Hashes[i] != zeroHash {
+				// responder loses because it hides something after its last leaf
+				return cidx, ReasonNonEmptyPadding
+			}
+		}
+		m.send(cidx, nc)
 		// wait for the index to open next
-		on, ok := (<-v.From[cidx]).(OpenNext)
+		on, ok := m.recv(cidx).(OpenNext)
 		if !ok {
-			return pidx
+			return pidx, ReasonChallengerMessage
+		}
+		if on.Index < 0 || on.Index >= n || on.Index*responderCap >= responderSize {
+			return pidx, ReasonChallengerIndex
+		}
+		if len(on.Hashes) != n {
+			return pidx, ReasonChallengerMessage
+		}
+		if on.Hashes[on.Index] == nc.Hashes[on.Index] {
+			// the challenger picks a child it agrees with
+			return pidx, ReasonChallengerAgrees
+		}
+		m.claims = append(m.claims, claim{height(m.Dim, responderCap), pos * n, on.Hashes})
+		m.choices = append(m.choices, on.Index)
+		responderSize -= on.Index * responderCap
+		if responderSize > responderCap {
+			responderSize = responderCap
 		}
-		v.To[pidx] <- on
-		responderSize /= v.Dim
 		responderPtr = nc.Hashes[on.Index]
-		diffIdx = diffIdx*v.Dim + on.Index
-	}
-	var diffPrevTreeIdx int // the tree root idx of the leaf prev to the diff point (st.From below)
-	if diffIdx == 0 {
-		diffPrevTreeIdx = sr.Index - 1
-	} else {
-		diffPrevTreeIdx = sr.Index
+		level, pos = height(m.Dim, responderCap), pos*n+on.Index
+		if m.Stateless {
+			m.send(pidx, OpenNode{responderPtr, level, pos, depth, m.Ledger})
+		} else {
+			// the prover does not need the nodes of the challenger
+			m.send(pidx, OpenNext{Index: on.Index, Depth: depth})
+		}
+		diffIdx = diffIdx*n + on.Index
 	}
 	// finish computing the diff index by adding the sizes the subtrees that are skipped
 	{
@@ -75,85 +439,416 @@ func (v *Verifier) Match(cidx, pidx int, pmr MountainRange) int {
 		}
 	}
 
+	m.diffIdx = diffIdx
+
 	// wait for the responder to open the leaf
-	st, ok := (<-v.From[pidx]).(StateTransition)
+	st, ok := m.recv(pidx).(StateTransition)
 	if !ok {
-		return cidx
+		return cidx, ReasonProverMessage
 	}
-	// TODO: verify if st.To has index diffIdx and st.From has index diffIdx-1
-	if v.MerkleHasher.HashData(st.To) != responderPtr {
+	m.leaf = &st
+	m.finished = true
+	// st.To is the leaf at diffIdx as it hashes to the node that the game descended
+	// to, and st.From must be the leaf at diffIdx-1 by the position of its proof
+	if leaf := m.hasher.HashData(st.To); leaf != responderPtr {
+		if m.weighted() && WeightOf(leaf) != WeightOf(responderPtr) {
+			return cidx, ReasonWeightMismatch
+		}
 		// incorrect hash of the opened leaf
-		return cidx
+		return cidx, ReasonLeafMismatch
+	}
+	if reason, ok := m.checkFrom(pmr, diffIdx, st); !ok {
+		return cidx, reason
+	}
+	if m.ValidTransition != nil && !m.ValidTransition(st.From, st.To, st.Witness) {
+		return cidx, ReasonInvalidTransition
 	}
-	if diffIdx != 0 {
-		if !v.MerkleHasher.CheckProof(st.From, st.FromProof, pmr.Roots[diffPrevTreeIdx]) {
-			// incorrect proof of the previous node
-			return cidx
+	// a valid transition of the prover only shows that it did not cheat; the
+	// challenger proves the nodes it opened, and forks of a weighted ledger may all
+	// be valid, in which case the heavier one is the ledger
+	return m.checkChallenger(diffIdx)
+}
+
+// checkFrom checks the leaf before the one at idx that st opens against the
+// mountain range, and returns why it fails if it does.
+func (m *match) checkFrom(mr MountainRange, idx int, st StateTransition) (Reason, bool) {
+	if idx != 0 {
+		if !m.checkLeafProof(mr, st.From, idx-1, st.FromProof) {
+			// incorrect proof of the previous node, or the proof of another leaf
+			return ReasonPrevLeafProof, false
 		}
+	} else if len(st.FromProof) != 0 || st.From != nil {
+		// nonempty prev node when the diff is at index 0
+		return ReasonPrevLeafAtZero, false
+	}
+	return "", true
+}
+
+// checkChallenger asks the challenger to open its own transition into the leaf at
+// idx, after the prover has opened a valid one there, and checks the nodes that the
+// challenger opened along the game against the proof of its leaf. The prover wins
+// unless the ledger is weighted, in which case both of them win if the transition
+// of the challenger is valid too.
+func (m *match) checkChallenger(idx int) (int, Reason) {
+	cmr, ok := m.challengerRange()
+	if !ok {
+		return m.pidx, ReasonChallengerMessage
+	}
+	m.crange = cmr
+	if idx >= cmr.NumLeaves() {
+		// the ledger of the challenger is a prefix of the one of the prover, so it
+		// cannot be the heavier one
+		return m.pidx, ReasonValidTransition
+	}
+	m.send(m.cidx, GetTransition{idx, m.Ledger})
+	st, ok := m.recv(m.cidx).(StateTransition)
+	if !ok {
+		return m.pidx, ReasonChallengerMessage
+	}
+	if !m.checkLeafProof(cmr, st.To, idx, st.ToProof) {
+		return m.pidx, ReasonLeafMismatch
+	}
+	if !m.checkClaims(cmr, idx, st.ToProof) {
+		return m.pidx, ReasonChallengerClaims
+	}
+	if !m.weighted() {
+		return m.pidx, ReasonValidTransition
+	}
+	if reason, ok := m.checkFrom(cmr, idx, st); !ok {
+		return m.pidx, reason
+	}
+	if m.ValidTransition != nil && !m.ValidTransition(st.From, st.To, st.Witness) {
+		return m.pidx, ReasonInvalidTransition
+	}
+	return BothWin, ReasonValidForks
+}
+
+// checkClaims tells if the nodes that the challenger opened along the game are the
+// ones on the path to its leaf at idx, which the proof of the leaf against its
+// mountain range holds level by level. Only the nodes over leaves that both ledgers
+// have are checked, as the challenger opens its nodes over the leaves of the
+// prover, and the proof holds them over its own.
+func (m *match) checkClaims(cmr MountainRange, idx int, proof []Hash) bool {
+	n := cmr.NumLeaves()
+	if m.limit < n {
+		n = m.limit
+	}
+	for _, c := range m.claims {
+		if (c.level+1)*m.Dim > len(proof) {
+			// above the root of the challenger
+			continue
+		}
+		size := 1
+		for i := 0; i < c.level; i++ {
+			size *= m.Dim
+		}
+		first := idx / size / m.Dim * m.Dim
+		for j, h := range proof[c.level*m.Dim : (c.level+1)*m.Dim] {
+			q := first + j
+			if (q+1)*size > n {
+				continue
+			}
+			if q < c.base || q >= c.base+len(c.hashes) || c.hashes[q-c.base] != h {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkLeafProof tells if the proof is the path from the position of leaf idx up to
+// the root of the mountain range over it, and not just a path from some leaf with
+// the same data, so that the prover cannot open another leaf in its place.
+func (m *match) checkLeafProof(mr MountainRange, data []byte, idx int, proof []Hash) bool {
+	start := 0
+	for i, sz := range mr.Sizes {
+		if idx >= start+sz {
+			start += sz
+			continue
+		}
+		level, _ := rootPosition(m.Dim, start, sz)
+		if len(proof) != level*m.Dim {
+			return false
+		}
+		h := m.hasher.HashData(data)
+		pos := idx
+		for ; len(proof) > 0; proof = proof[m.Dim:] {
+			if proof[pos%m.Dim] != h {
+				return false
+			}
+			h = m.hasher.ComputeParent(proof[:m.Dim])
+			pos /= m.Dim
+		}
+		return h == mr.Roots[i]
+	}
+	return false
+}
+
+// checkNested tells if the NestedLedger of the challenger proves that the ledger
+// of the prover is a prefix of the one of the challenger.
+func (m *match) checkNested(pmr MountainRange, nl NestedLedger) bool {
+	cmr, ok := m.challengerRange()
+	if !ok {
+		return false
+	}
+	m.crange = cmr
+	n := 0
+	for _, sz := range pmr.Sizes {
+		n += sz
+	}
+	// the subtrees before the end of the ledger of the prover are shared by both
+	return m.checkSuffix(m.hasher, cmr, n, Suffix(nl)) && m.checkSuffix(m.hasher, pmr, n, Suffix{Prefix: nl.Prefix})
+}
+
+// committedRange returns the mountain range of the challenger if it was fetched
+// before the game. Unlike challengerRange, it does not ask the challenger, who
+// could answer anything when it is not bound to the range.
+func (m *match) committedRange() (MountainRange, bool) {
+	if m.cidx < len(m.ranges) && len(m.ranges[m.cidx].Roots) != 0 {
+		m.crange = m.ranges[m.cidx]
+		return m.crange, true
+	}
+	return MountainRange{}, false
+}
+
+// sameRoot tells if both mountain ranges have the same root at idx over the same
+// leaves.
+func sameRoot(a, b MountainRange, idx int) bool {
+	if idx >= len(a.Roots) || idx >= len(b.Roots) {
+		return false
+	}
+	for i := 0; i <= idx; i++ {
+		if a.Sizes[i] != b.Sizes[i] {
+			return false
+		}
+	}
+	return a.Roots[idx] == b.Roots[idx]
+}
+
+// challengerRange returns the mountain range of the challenger, which Run and Match
+// fetch before the game. Replays of matches that did not record it ask the
+// challenger.
+func (m *match) challengerRange() (MountainRange, bool) {
+	if cmr, ok := m.committedRange(); ok {
+		return cmr, true
+	}
+	m.send(m.cidx, GetMountainRange{m.Ledger})
+	cmr, ok := m.recv(m.cidx).(MountainRange)
+	return cmr, ok && validMountainRange(m.Dim, cmr)
+}
+
+// ancestor computes the node that the descendants of some levels below it hash to.
+// Groups of empty descendants below the node have an empty parent.
+func (m *match) ancestor(nodes []Hash) Hash {
+	for len(nodes) > m.Dim {
+		next := make([]Hash, len(nodes)/m.Dim)
+		for i := range next {
+			group := nodes[i*m.Dim : i*m.Dim+m.Dim]
+			for _, h := range group {
+				if h != zeroHash {
+					next[i] = m.hasher.ComputeParent(group)
+					break
+				}
+			}
+		}
+		nodes = next
+	}
+	return m.hasher.ComputeParent(nodes)
+}
+
+// receive waits for the next message from the server. It returns false if the
+// server does not answer in time or has disconnected.
+func (v *Verifier) receive(from int) (Message, bool) {
+	var msg Message
+	var ok bool
+	if v.Timeout == 0 {
+		msg, ok = <-v.From[from]
 	} else {
-		if len(st.FromProof) != 0 || st.From != nil {
-			// nonempty prev node when the diff is at index 0
-			return cidx
+		timer := time.NewTimer(v.Timeout)
+		select {
+		case msg, ok = <-v.From[from]:
+			timer.Stop()
+		case <-timer.C:
 		}
 	}
-	// TODO: verify the state transition
-	return pidx
+	if !ok {
+		v.lock.Lock()
+		if v.stalled == nil {
+			v.stalled = make(map[int]struct{})
+		}
+		v.stalled[from] = struct{}{}
+		v.lock.Unlock()
+	}
+	return msg, ok
+}
+
+func (v *Verifier) isStalled(i int) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	_, stalled := v.stalled[i]
+	return stalled
 }
 
-func (v *Verifier) Run() (MountainRange, int) {
+// Run plays the tournament among the servers, and returns the mountain range of
+// the winner, the index of the winner, and the statistics of the matches played.
+func (v *Verifier) Run() (MountainRange, int, []MatchStats) {
 	if len(v.To) != len(v.From) {
 		panic("verifier launched with different incoming channels and outgoing channels")
 	}
 
+	mr, parties := v.fetchMountainRanges()
+	if len(parties) == 0 {
+		return MountainRange{}, NoWinner, nil
+	}
+
+	var winner int
+	var stats []MatchStats
+	if v.Parallel {
+		winner, stats = v.runBracket(parties, mr)
+	} else {
+		winner, stats = v.runSequential(parties, mr)
+	}
+	return mr[winner], winner, stats
+}
+
+// weighted tells if the hashes commit to the weights of the ledgers.
+func (v *Verifier) weighted() bool {
+	_, ok := v.MerkleHasher.(*WeightedHasher)
+	return ok
+}
+
+// larger tells if the ledger of a is larger than the one of b, by weight if the
+// verifier is weighted, and then by the number of leaves. A ledger with the
+// leaves of another one and more is never smaller, as leaves do not weigh less
+// than nothing, so it is the one to challenge.
+func (v *Verifier) larger(a, b MountainRange) bool {
+	if v.weighted() && a.Weight() != b.Weight() {
+		return a.Weight() > b.Weight()
+	}
+	return a.NumLeaves() > b.NumLeaves()
+}
+
+// fetchMountainRanges asks every server for its mountain range concurrently, and
+// returns the mountain ranges and the indices of the servers that sent a valid
+// one in time.
+func (v *Verifier) fetchMountainRanges() ([]MountainRange, []int) {
 	// wait for everyone to send the mountain range
-	// TODO: timeout
 	mr := make([]MountainRange, len(v.From))
+	valid := make([]bool, len(v.From))
+	reasons := make([]Reason, len(v.From))
+	wg := &sync.WaitGroup{}
 	for i := range mr {
-		v.To[i] <- GetMountainRange{}
-		mr[i] = (<-v.From[i]).(MountainRange)
-		if len(mr[i].Sizes) != len(mr[i].Roots) {
-			panic("different length of root and size array")
-		}
-		if len(mr[i].Sizes) > 1 {
-			for j := 1; j < len(mr[i].Sizes); j++ {
-				if mr[i].Sizes[j] > mr[i].Sizes[j-1] {
-					panic("increasing size in size array")
-				}
-				if mr[i].Sizes[j-1]%mr[i].Sizes[j] != 0 {
-					panic("noninteger size scale")
-				}
-				scale := mr[i].Sizes[j-1] / mr[i].Sizes[j]
-				for scale != 1 {
-					if scale%v.Dim != 0 {
-						panic("scale not exponential of dimension")
-					}
-					scale = scale / v.Dim
-				}
-			}
+		if v.isStalled(i) {
+			continue
 		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mr[i], reasons[i] = v.fetchMountainRange(i)
+			valid[i] = reasons[i] == ""
+		}(i)
 	}
+	wg.Wait()
 
-	sizes := make([]int, len(v.From))
-	for i := range sizes {
-		for _, s := range mr[i].Sizes {
-			sizes[i] += s
+	var parties []int
+	v.reportLock.Lock()
+	defer v.reportLock.Unlock()
+	for i := range mr {
+		if valid[i] {
+			parties = append(parties, i)
+		} else if reasons[i] == ReasonUnsupportedLedger && v.ReportResult != nil {
+			v.ReportResult(MatchResult{VerifierIndex, i, NoWinner, reasons[i]})
+		} else if reasons[i] != "" && v.ReportResult != nil {
+			v.ReportResult(MatchResult{VerifierIndex, i, VerifierIndex, reasons[i]})
+		}
+	}
+	return mr, parties
+}
+
+// fetchMountainRange asks the server for its mountain range of the ledger, and
+// returns it, or why the server failed to send a valid one.
+func (v *Verifier) fetchMountainRange(i int) (MountainRange, Reason) {
+	v.To[i] <- GetMountainRange{v.Ledger}
+	msg, ok := v.receive(i)
+	if !ok {
+		return MountainRange{}, ReasonTimeout
+	}
+	if _, ok := msg.(Terminate); ok {
+		// the session answers so for a ledger it does not host
+		return MountainRange{}, ReasonUnsupportedLedger
+	}
+	mr, ok := msg.(MountainRange)
+	if !ok || !validMountainRange(v.Dim, mr) {
+		return MountainRange{}, ReasonMountainRange
+	}
+	return mr, ""
+}
+
+// maxRootSize bounds the number of leaves under a root, far above any ledger we
+// can store, so that computing the capacity of a root does not overflow.
+const maxRootSize = 1 << 48
+
+// validMountainRange checks that the roots of the mountain range are perfect trees
+// of decreasing sizes, as NewKVMerkleTree builds them, i.e. with less than Dim
+// trees of each size, except that the last root may be a partial tree. A partial
+// tree must fit in a perfect tree no larger than the root before it, so that it
+// starts at a multiple of its capacity.
+func validMountainRange(dim int, mr MountainRange) bool {
+	if len(mr.Sizes) != len(mr.Roots) {
+		// different length of root and size array
+		return false
+	}
+	for j := range mr.Sizes {
+		if mr.Sizes[j] < 1 || mr.Sizes[j] > maxRootSize {
+			return false
+		}
+		if j < len(mr.Sizes)-1 && capacity(dim, mr.Sizes[j]) != mr.Sizes[j] {
+			// partial tree before the last root
+			return false
+		}
+		if j >= dim-1 && mr.Sizes[j] == mr.Sizes[j-dim+1] {
+			// Dim trees of the same size should have been one larger tree
+			return false
+		}
+		if j == 0 {
+			continue
+		}
+		if capacity(dim, mr.Sizes[j]) > mr.Sizes[j-1] {
+			// increasing size in size array; as both are powers of Dim, the
+			// scale between them is also a power of Dim otherwise
+			return false
 		}
 	}
+	return true
+}
+
+// matchPair uses whoever that is larger to challenge the other, and returns the
+// result of the match.
+func (v *Verifier) matchPair(a, b int, mr []MountainRange) (int, MatchStats) {
+	if v.larger(mr[a], mr[b]) {
+		return v.runMatch(mr, a, b, mr[b], v.Depth)
+	} else {
+		return v.runMatch(mr, b, a, mr[a], v.Depth)
+	}
+}
+
+// runSequential adds the servers one by one into the safe set, letting each new
+// server play against the largest safe server until one of them is out.
+func (v *Verifier) runSequential(parties []int, mr []MountainRange) (int, []MatchStats) {
 	safe := make(map[int]struct{})
+	var stats []MatchStats
 
-	findLargestSafe := func() (int, int) {
+	findLargestSafe := func() int {
 		largestSafe := -1
-		largestSafeSize := -1
 		for k := range safe {
-			if sizes[k] > largestSafeSize {
+			if largestSafe == -1 || v.larger(mr[k], mr[largestSafe]) {
 				largestSafe = k
-				largestSafeSize = sizes[k]
 			}
 		}
-		return largestSafe, largestSafeSize
+		return largestSafe
 	}
 
-	for i := range sizes {
+	for _, i := range parties {
 		if len(safe) == 0 {
 			// no one is safe; the current peer automatically wins
 			safe[i] = struct{}{}
@@ -161,16 +856,12 @@ func (v *Verifier) Run() (MountainRange, int) {
 			// use the current peer to challenge the largest peer in the safe set
 			// until all safe peer have lost, or the current peer has lost, or both win
 			for {
-				// use whoever that is larger to challenge the other
-				largestSafe, largestSafeSize := findLargestSafe()
-				var res int
-				if largestSafeSize > sizes[i] {
-					res = v.Match(largestSafe, i, mr[i])
-				} else {
-					res = v.Match(i, largestSafe, mr[largestSafe])
-				}
+				largestSafe := findLargestSafe()
+				res, st := v.matchPair(largestSafe, i, mr)
+				stats = append(stats, st)
 				if res == BothWin {
-					// both wins; the ledgers appear to be compatible
+					// both wins; the ledgers appear to be compatible, or are valid
+					// forks of a weighted ledger, and the larger one is safe first
 					safe[i] = struct{}{}
 					break
 				} else if res == i {
@@ -190,6 +881,83 @@ func (v *Verifier) Run() (MountainRange, int) {
 		}
 	}
 
-	winner, _ := findLargestSafe()
-	return mr[winner], winner
+	return findLargestSafe(), stats
+}
+
+// runBracket plays the tournament in rounds. Servers that have not lost any match
+// are kept in groups of servers whose ledgers appear compatible. In each round,
+// the groups are paired up, and the largest servers of each pair of groups play a
+// match. Matches of different pairs involve different servers, so they run in
+// parallel. A server only leaves the tournament by losing a match, as in
+// runSequential, so an honest server is never eliminated.
+func (v *Verifier) runBracket(parties []int, mr []MountainRange) (int, []MatchStats) {
+	largest := func(g []int) int {
+		l := g[0]
+		for _, k := range g {
+			if v.larger(mr[k], mr[l]) {
+				l = k
+			}
+		}
+		return l
+	}
+	remove := func(g []int, k int) []int {
+		var res []int
+		for _, x := range g {
+			if x != k {
+				res = append(res, x)
+			}
+		}
+		return res
+	}
+
+	var stats []MatchStats
+	var groups [][]int
+	for _, i := range parties {
+		groups = append(groups, []int{i})
+	}
+	for len(groups) > 1 {
+		npairs := len(groups) / 2
+		res := make([]int, npairs)
+		st := make([]MatchStats, npairs)
+		wg := &sync.WaitGroup{}
+		for p := 0; p < npairs; p++ {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				res[p], st[p] = v.matchPair(largest(groups[2*p]), largest(groups[2*p+1]), mr)
+			}(p)
+		}
+		wg.Wait()
+		stats = append(stats, st...)
+
+		var next [][]int
+		for p := 0; p < npairs; p++ {
+			a, b := groups[2*p], groups[2*p+1]
+			la, lb := largest(a), largest(b)
+			if res[p] == BothWin {
+				// the ledgers appear to be compatible, or are valid forks of a
+				// weighted ledger; merge the groups, led by the larger one
+				next = append(next, append(append([]int{}, a...), b...))
+				continue
+			} else if res[p] == la {
+				b = remove(b, lb)
+			} else if res[p] == lb {
+				a = remove(a, la)
+			} else {
+				panic("unreachable")
+			}
+			if len(a) != 0 {
+				next = append(next, a)
+			}
+			if len(b) != 0 {
+				next = append(next, b)
+			}
+		}
+		if len(groups)%2 == 1 {
+			// the last group did not play in this round; let it play first in the next
+			next = append([][]int{groups[len(groups)-1]}, next...)
+		}
+		groups = next
+	}
+	return largest(groups[0]), stats
 }
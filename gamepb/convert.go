@@ -0,0 +1,137 @@
+package gamepb
+
+import (
+	"github.com/yangl1996/super-light-client/game"
+)
+
+func toHashes(hs []game.Hash) [][]byte {
+	res := make([][]byte, len(hs))
+	for i := range hs {
+		res[i] = hs[i][:]
+	}
+	return res
+}
+
+func fromHashes(bs [][]byte) []game.Hash {
+	res := make([]game.Hash, len(bs))
+	for i := range bs {
+		copy(res[i][:], bs[i])
+	}
+	return res
+}
+
+func toInts(is []int) []int64 {
+	res := make([]int64, len(is))
+	for i := range is {
+		res[i] = int64(is[i])
+	}
+	return res
+}
+
+func fromInts(is []int64) []int {
+	res := make([]int, len(is))
+	for i := range is {
+		res[i] = int(is[i])
+	}
+	return res
+}
+
+// FromMountainRange converts a game.MountainRange to its protobuf message.
+func FromMountainRange(mr game.MountainRange) *MountainRange {
+	return &MountainRange{Roots: toHashes(mr.Roots), Sizes: toInts(mr.Sizes)}
+}
+
+// ToMountainRange converts a protobuf MountainRange to a game.MountainRange.
+func ToMountainRange(mr *MountainRange) game.MountainRange {
+	return game.MountainRange{Roots: fromHashes(mr.Roots), Sizes: fromInts(mr.Sizes)}
+}
+
+// FromMessage wraps a message of package game into a GameMessage.
+func FromMessage(msg game.Message) *GameMessage {
+	switch m := msg.(type) {
+	case game.GetMountainRange:
+		return &GameMessage{Message: &GameMessage_GetMountainRange{&GetMountainRange{Ledger: m.Ledger}}}
+	case game.NestedLedger:
+		return &GameMessage{Message: &GameMessage_NestedLedger{&NestedLedger{Prefix: toHashes(m.Prefix), Suffix: toHashes(m.Suffix)}}}
+	case game.Terminate:
+		return &GameMessage{Message: &GameMessage_Terminate{&Terminate{}}}
+	case game.OpenNext:
+		return &GameMessage{Message: &GameMessage_OpenNext{&OpenNext{Index: int64(m.Index), Hashes: toHashes(m.Hashes), Depth: int64(m.Depth)}}}
+	case game.StartRoot:
+		return &GameMessage{Message: &GameMessage_StartRoot{&StartRoot{Index: int64(m.Index), Depth: int64(m.Depth), Ledger: m.Ledger}}}
+	case game.OpenNode:
+		return &GameMessage{Message: &GameMessage_OpenNode{&OpenNode{Hash: m.Hash[:], Level: int64(m.Level), Pos: int64(m.Pos), Depth: int64(m.Depth), Ledger: m.Ledger}}}
+	case game.NextChildren:
+		return &GameMessage{Message: &GameMessage_NextChildren{&NextChildren{Hashes: toHashes(m.Hashes)}}}
+	case game.StateTransition:
+		return &GameMessage{Message: &GameMessage_StateTransition{&StateTransition{
+			From:      m.From,
+			FromProof: toHashes(m.FromProof),
+			To:        m.To,
+			Witness:   m.Witness,
+			ToProof:   toHashes(m.ToProof),
+		}}}
+	case game.MountainRange:
+		return &GameMessage{Message: &GameMessage_MountainRange_{FromMountainRange(m)}}
+	case game.GetSuffix:
+		return &GameMessage{Message: &GameMessage_GetSuffix{&GetSuffix{Index: int64(m.Index), Ledger: m.Ledger}}}
+	case game.Suffix:
+		return &GameMessage{Message: &GameMessage_Suffix_{&Suffix{Prefix: toHashes(m.Prefix), Suffix: toHashes(m.Suffix)}}}
+	case game.GetTransition:
+		return &GameMessage{Message: &GameMessage_GetTransition{&GetTransition{Index: int64(m.Index), Ledger: m.Ledger}}}
+	default:
+		panic("unknown message type")
+	}
+}
+
+// ToMessage unwraps a GameMessage into the corresponding message of package game.
+// It returns nil if the GameMessage is empty.
+func ToMessage(msg *GameMessage) game.Message {
+	switch m := msg.Message.(type) {
+	case *GameMessage_GetMountainRange:
+		return game.GetMountainRange{Ledger: m.GetMountainRange.Ledger}
+	case *GameMessage_NestedLedger:
+		return game.NestedLedger{Prefix: fromHashes(m.NestedLedger.Prefix), Suffix: fromHashes(m.NestedLedger.Suffix)}
+	case *GameMessage_Terminate:
+		return game.Terminate{}
+	case *GameMessage_OpenNext:
+		on := game.OpenNext{Index: int(m.OpenNext.Index), Depth: int(m.OpenNext.Depth)}
+		if len(m.OpenNext.Hashes) != 0 {
+			on.Hashes = fromHashes(m.OpenNext.Hashes)
+		}
+		return on
+	case *GameMessage_StartRoot:
+		return game.StartRoot{Index: int(m.StartRoot.Index), Depth: int(m.StartRoot.Depth), Ledger: m.StartRoot.Ledger}
+	case *GameMessage_OpenNode:
+		var h game.Hash
+		copy(h[:], m.OpenNode.Hash)
+		return game.OpenNode{Hash: h, Level: int(m.OpenNode.Level), Pos: int(m.OpenNode.Pos), Depth: int(m.OpenNode.Depth), Ledger: m.OpenNode.Ledger}
+	case *GameMessage_NextChildren:
+		return game.NextChildren{Hashes: fromHashes(m.NextChildren.Hashes)}
+	case *GameMessage_StateTransition:
+		st := m.StateTransition
+		// keep a missing prev leaf as nil, as the verifier tells index 0 apart by it
+		var from []byte
+		var fromProof, toProof []game.Hash
+		if st.From != nil {
+			from = st.From
+		}
+		if len(st.FromProof) != 0 {
+			fromProof = fromHashes(st.FromProof)
+		}
+		if len(st.ToProof) != 0 {
+			toProof = fromHashes(st.ToProof)
+		}
+		return game.StateTransition{From: from, FromProof: fromProof, To: st.To, Witness: st.Witness, ToProof: toProof}
+	case *GameMessage_MountainRange_:
+		return ToMountainRange(m.MountainRange_)
+	case *GameMessage_GetSuffix:
+		return game.GetSuffix{Index: int(m.GetSuffix.Index), Ledger: m.GetSuffix.Ledger}
+	case *GameMessage_Suffix_:
+		return game.Suffix{Prefix: fromHashes(m.Suffix_.Prefix), Suffix: fromHashes(m.Suffix_.Suffix)}
+	case *GameMessage_GetTransition:
+		return game.GetTransition{Index: int(m.GetTransition.Index), Ledger: m.GetTransition.Ledger}
+	default:
+		return nil
+	}
+}
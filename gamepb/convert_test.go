@@ -0,0 +1,37 @@
+package gamepb
+
+import (
+	"reflect"
+	"testing"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	msgs := []game.Message{
+		game.GetMountainRange{},
+		game.GetMountainRange{Ledger: "b"},
+		game.NestedLedger{Prefix: []game.Hash{{12}, {13}}, Suffix: []game.Hash{{14}}},
+		game.Terminate{},
+		game.OpenNext{Index: 3, Depth: 2},
+		game.OpenNext{Index: 1, Hashes: []game.Hash{{4}, {5}}, Depth: 2},
+		game.StartRoot{Index: 1, Depth: 2},
+		game.StartRoot{Index: 1, Depth: 2, Ledger: "b"},
+		game.OpenNode{Hash: game.Hash{8}, Level: 2, Pos: 17, Depth: 3},
+		game.OpenNode{Hash: game.Hash{8}, Level: 2, Pos: 17, Depth: 3, Ledger: "b"},
+		game.NextChildren{Hashes: []game.Hash{{1}, {2}, {3}}},
+		game.StateTransition{From: []byte("a"), FromProof: []game.Hash{{4}, {5}}, To: []byte("b")},
+		game.StateTransition{From: nil, FromProof: nil, To: []byte("b")},
+		game.StateTransition{From: []byte("a"), FromProof: []game.Hash{{4}}, To: []byte("b"), Witness: []byte("w")},
+		game.StateTransition{From: []byte("a"), FromProof: []game.Hash{{4}}, To: []byte("b"), ToProof: []game.Hash{{5}, {6}}},
+		game.MountainRange{Roots: []game.Hash{{6}, {7}}, Sizes: []int{9, 3}},
+		game.GetSuffix{Index: 7},
+		game.GetSuffix{Index: 7, Ledger: "b"},
+		game.Suffix{Prefix: []game.Hash{{9}}, Suffix: []game.Hash{{10}, {11}}},
+		game.GetTransition{Index: 5, Ledger: "b"},
+	}
+	for _, m := range msgs {
+		if res := ToMessage(FromMessage(m)); !reflect.DeepEqual(res, m) {
+			t.Errorf("message %#v converted to %#v", m, res)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package gamepb defines the gRPC service of the bisection game, and converts
+// between its protobuf messages and the messages of package game.
+package gamepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative game.proto
@@ -0,0 +1,214 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: game.proto
+
+package gamepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// BisectionClient is the client API for Bisection service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BisectionClient interface {
+	// Play runs a session of the bisection game. The client sends the messages of
+	// the verifier and the server answers as game.Session does over TCP.
+	Play(ctx context.Context, opts ...grpc.CallOption) (Bisection_PlayClient, error)
+	MountainRangeOf(ctx context.Context, in *GetMountainRange, opts ...grpc.CallOption) (*MountainRange, error)
+	LeafAt(ctx context.Context, in *LeafRequest, opts ...grpc.CallOption) (*Leaf, error)
+}
+
+type bisectionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBisectionClient(cc grpc.ClientConnInterface) BisectionClient {
+	return &bisectionClient{cc}
+}
+
+func (c *bisectionClient) Play(ctx context.Context, opts ...grpc.CallOption) (Bisection_PlayClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Bisection_ServiceDesc.Streams[0], "/superlightclient.game.Bisection/Play", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bisectionPlayClient{stream}
+	return x, nil
+}
+
+type Bisection_PlayClient interface {
+	Send(*GameMessage) error
+	Recv() (*GameMessage, error)
+	grpc.ClientStream
+}
+
+type bisectionPlayClient struct {
+	grpc.ClientStream
+}
+
+func (x *bisectionPlayClient) Send(m *GameMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *bisectionPlayClient) Recv() (*GameMessage, error) {
+	m := new(GameMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bisectionClient) MountainRangeOf(ctx context.Context, in *GetMountainRange, opts ...grpc.CallOption) (*MountainRange, error) {
+	out := new(MountainRange)
+	err := c.cc.Invoke(ctx, "/superlightclient.game.Bisection/MountainRangeOf", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bisectionClient) LeafAt(ctx context.Context, in *LeafRequest, opts ...grpc.CallOption) (*Leaf, error) {
+	out := new(Leaf)
+	err := c.cc.Invoke(ctx, "/superlightclient.game.Bisection/LeafAt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BisectionServer is the server API for Bisection service.
+// All implementations must embed UnimplementedBisectionServer
+// for forward compatibility
+type BisectionServer interface {
+	// Play runs a session of the bisection game. The client sends the messages of
+	// the verifier and the server answers as game.Session does over TCP.
+	Play(Bisection_PlayServer) error
+	MountainRangeOf(context.Context, *GetMountainRange) (*MountainRange, error)
+	LeafAt(context.Context, *LeafRequest) (*Leaf, error)
+	mustEmbedUnimplementedBisectionServer()
+}
+
+// UnimplementedBisectionServer must be embedded to have forward compatible implementations.
+type UnimplementedBisectionServer struct {
+}
+
+func (UnimplementedBisectionServer) Play(Bisection_PlayServer) error {
+	return status.Errorf(codes.Unimplemented, "method Play not implemented")
+}
+func (UnimplementedBisectionServer) MountainRangeOf(context.Context, *GetMountainRange) (*MountainRange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MountainRangeOf not implemented")
+}
+func (UnimplementedBisectionServer) LeafAt(context.Context, *LeafRequest) (*Leaf, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeafAt not implemented")
+}
+func (UnimplementedBisectionServer) mustEmbedUnimplementedBisectionServer() {}
+
+// UnsafeBisectionServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BisectionServer will
+// result in compilation errors.
+type UnsafeBisectionServer interface {
+	mustEmbedUnimplementedBisectionServer()
+}
+
+func RegisterBisectionServer(s grpc.ServiceRegistrar, srv BisectionServer) {
+	s.RegisterService(&Bisection_ServiceDesc, srv)
+}
+
+func _Bisection_Play_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BisectionServer).Play(&bisectionPlayServer{stream})
+}
+
+type Bisection_PlayServer interface {
+	Send(*GameMessage) error
+	Recv() (*GameMessage, error)
+	grpc.ServerStream
+}
+
+type bisectionPlayServer struct {
+	grpc.ServerStream
+}
+
+func (x *bisectionPlayServer) Send(m *GameMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *bisectionPlayServer) Recv() (*GameMessage, error) {
+	m := new(GameMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Bisection_MountainRangeOf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMountainRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BisectionServer).MountainRangeOf(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/superlightclient.game.Bisection/MountainRangeOf",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BisectionServer).MountainRangeOf(ctx, req.(*GetMountainRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bisection_LeafAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeafRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BisectionServer).LeafAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/superlightclient.game.Bisection/LeafAt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BisectionServer).LeafAt(ctx, req.(*LeafRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Bisection_ServiceDesc is the grpc.ServiceDesc for Bisection service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Bisection_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "superlightclient.game.Bisection",
+	HandlerType: (*BisectionServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MountainRangeOf",
+			Handler:    _Bisection_MountainRangeOf_Handler,
+		},
+		{
+			MethodName: "LeafAt",
+			Handler:    _Bisection_LeafAt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Play",
+			Handler:       _Bisection_Play_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "game.proto",
+}
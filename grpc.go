@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"github.com/yangl1996/super-light-client/game"
+	"github.com/yangl1996/super-light-client/gamepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// bisectionServer serves the bisection game over gRPC by running a game.Session
+// for each Play stream, the same way handleConn does for raw TCP.
+type bisectionServer struct {
+	gamepb.UnimplementedBisectionServer
+	ledgers ledgers
+}
+
+func serveGRPC(addr string, hosted ledgers) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := grpc.NewServer()
+	gamepb.RegisterBisectionServer(s, &bisectionServer{ledgers: hosted})
+	log.Fatal(s.Serve(l))
+}
+
+func (s *bisectionServer) Play(stream gamepb.Bisection_PlayServer) error {
+	log.Println("light client connected over grpc")
+	toPeer := make(chan game.Message, 100)
+	fromPeer := make(chan game.Message, 100)
+	read := make(chan error, 1)
+	go func() {
+		read <- readStream(stream, fromPeer)
+	}()
+	sess := &game.Session{
+		Tree:    s.ledgers[""],
+		Ledgers: s.ledgers,
+		I:       fromPeer,
+		O:       toPeer,
+	}
+	go sess.Run()
+	err := writeStream(stream, toPeer)
+	// the session ends once the client stops sending, so the read is over too
+	if rerr := <-read; status.Code(rerr) == codes.InvalidArgument {
+		err = rerr
+	}
+	log.Println("light client disconnecting")
+	return err
+}
+
+// tree returns the tree of the named ledger.
+func (s *bisectionServer) tree(name string) (game.MerkleTree, error) {
+	t, ok := s.ledgers[name]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown ledger")
+	}
+	return t, nil
+}
+
+func (s *bisectionServer) MountainRangeOf(ctx context.Context, req *gamepb.GetMountainRange) (*gamepb.MountainRange, error) {
+	tree, err := s.tree(req.Ledger)
+	if err != nil {
+		return nil, err
+	}
+	return gamepb.FromMountainRange(game.NewMountainRange(tree)), nil
+}
+
+func (s *bisectionServer) LeafAt(ctx context.Context, req *gamepb.LeafRequest) (*gamepb.Leaf, error) {
+	tree, err := s.tree(req.Ledger)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, sz := range game.NewMountainRange(tree).Sizes {
+		n += sz
+	}
+	if req.Index < 0 || req.Index >= int64(n) {
+		return nil, status.Error(codes.NotFound, "leaf index out of range")
+	}
+	h := tree.GetLeaf(int(req.Index))
+	proof := tree.GetProof(int(req.Index))
+	leaf := &gamepb.Leaf{
+		Index: req.Index,
+		Hash:  h[:],
+		Data:  tree.GetData(int(req.Index)),
+	}
+	for i := range proof {
+		leaf.Proof = append(leaf.Proof, proof[i][:])
+	}
+	return leaf, nil
+}
+
+// gameStream is implemented by both ends of the Play stream.
+type gameStream interface {
+	Send(*gamepb.GameMessage) error
+	Recv() (*gamepb.GameMessage, error)
+}
+
+func readStream(stream gameStream, ch chan<- game.Message) error {
+	defer close(ch)
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		msg := gamepb.ToMessage(m)
+		if msg == nil {
+			return status.Error(codes.InvalidArgument, "unknown game message")
+		}
+		ch <- msg
+	}
+}
+
+func writeStream(stream gameStream, ch <-chan game.Message) error {
+	var err error
+	for m := range ch {
+		if err != nil {
+			continue
+		}
+		err = stream.Send(gamepb.FromMessage(m))
+	}
+	return err
+}
+
+// dialGRPC opens a Play stream to a gRPC server and returns the channels to talk
+// to it as a prover.
+func dialGRPC(addr string) (chan<- game.Message, <-chan game.Message) {
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatal(err)
+	}
+	stream, err := gamepb.NewBisectionClient(conn).Play(context.Background())
+	if err != nil {
+		log.Println(err)
+		return unreachable()
+	}
+	t := make(chan game.Message, 100)
+	f := make(chan game.Message, 100)
+	go readStream(stream, f)
+	go func() {
+		writeStream(stream, t)
+		stream.CloseSend()
+	}()
+	return t, f
+}
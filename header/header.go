@@ -0,0 +1,186 @@
+// Package header lets the bisection game run over a chain of Bitcoin-style block
+// headers, with one 80-byte header per leaf. It decodes the headers, checks that a
+// header extends the one before it with enough work, and weighs the headers by
+// their work for a game.WeightedHasher.
+package header
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+)
+
+// Size is the number of bytes of an encoded header.
+const Size = 80
+
+// Header is a block header. Hashes are in the byte order of the encoding, which
+// is the reverse of the one Bitcoin displays them in.
+type Header struct {
+	Version    int32
+	PrevBlock  [32]byte
+	MerkleRoot [32]byte
+	Timestamp  uint32
+	Bits       uint32 // the target in the compact encoding
+	Nonce      uint32
+}
+
+var ErrSize = errors.New("header is not 80 bytes")
+
+// Decode decodes a header from its 80 bytes.
+func Decode(b []byte) (Header, error) {
+	var h Header
+	if len(b) != Size {
+		return h, ErrSize
+	}
+	h.Version = int32(binary.LittleEndian.Uint32(b[0:4]))
+	copy(h.PrevBlock[:], b[4:36])
+	copy(h.MerkleRoot[:], b[36:68])
+	h.Timestamp = binary.LittleEndian.Uint32(b[68:72])
+	h.Bits = binary.LittleEndian.Uint32(b[72:76])
+	h.Nonce = binary.LittleEndian.Uint32(b[76:80])
+	return h, nil
+}
+
+// Encode returns the 80 bytes of the header.
+func (h Header) Encode() []byte {
+	b := make([]byte, Size)
+	binary.LittleEndian.PutUint32(b[0:4], uint32(h.Version))
+	copy(b[4:36], h.PrevBlock[:])
+	copy(b[36:68], h.MerkleRoot[:])
+	binary.LittleEndian.PutUint32(b[68:72], h.Timestamp)
+	binary.LittleEndian.PutUint32(b[72:76], h.Bits)
+	binary.LittleEndian.PutUint32(b[76:80], h.Nonce)
+	return b
+}
+
+// Hash returns the double SHA256 of the header, which the next header points to.
+func (h Header) Hash() [32]byte {
+	first := sha256.Sum256(h.Encode())
+	return sha256.Sum256(first[:])
+}
+
+// Target decodes the target of the compact encoding bits. It returns nil if the
+// target is negative, zero, or does not fit in 256 bits.
+func Target(bits uint32) *big.Int {
+	mantissa := bits & 0x007fffff
+	exponent := uint(bits >> 24)
+	if bits&0x00800000 != 0 || mantissa == 0 {
+		return nil
+	}
+	t := big.NewInt(int64(mantissa))
+	if exponent <= 3 {
+		t.Rsh(t, 8*(3-exponent))
+	} else {
+		t.Lsh(t, 8*(exponent-3))
+	}
+	if t.Sign() == 0 || t.BitLen() > 256 {
+		return nil
+	}
+	return t
+}
+
+// MeetsTarget tells if the hash of the header, read as a little-endian number, is
+// at most its target.
+func (h Header) MeetsTarget() bool {
+	target := Target(h.Bits)
+	if target == nil {
+		return false
+	}
+	hash := h.Hash()
+	// big.Int reads big-endian numbers
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return new(big.Int).SetBytes(hash[:]).Cmp(target) <= 0
+}
+
+// Work returns the expected number of hashes to find a header that meets the
+// target of bits, i.e. 2^256 / (target + 1), or zero if the target is invalid.
+func Work(bits uint32) *big.Int {
+	target := Target(bits)
+	if target == nil {
+		return new(big.Int)
+	}
+	work := new(big.Int).Lsh(big.NewInt(1), 256)
+	return work.Div(work, target.Add(target, big.NewInt(1)))
+}
+
+// Weight is the weight of an encoded header for a game.WeightedHasher: its work
+// in units of 2^32 hashes, about the work of a header at difficulty 1. A header
+// with less work, as on test networks, weighs 1, and one that cannot be decoded
+// weighs nothing.
+func Weight(data []byte) uint64 {
+	h, err := Decode(data)
+	if err != nil {
+		return 0
+	}
+	w := Work(h.Bits)
+	if w.Sign() == 0 {
+		return 0
+	}
+	w.Rsh(w, 32)
+	if w.Sign() == 0 {
+		return 1
+	}
+	if !w.IsUint64() {
+		return ^uint64(0)
+	}
+	return w.Uint64()
+}
+
+// ValidTransition tells if the header to extends the header from, i.e. it points
+// to the hash of from and meets its own target. From is nil if to is the first
+// header of the chain, which only needs to meet its target. Headers need no
+// witness. It can be used as game.Verifier.ValidTransition. It does not check the
+// changes of the target, which depend on more headers than the two, so a chain is
+// only as heavy as the work of the targets it picks.
+func ValidTransition(from, to, witness []byte) bool {
+	next, err := Decode(to)
+	if err != nil || !next.MeetsTarget() {
+		return false
+	}
+	if from == nil {
+		return true
+	}
+	prev, err := Decode(from)
+	if err != nil {
+		return false
+	}
+	return prev.Hash() == next.PrevBlock
+}
+
+// ReadAll reads a file of headers, which are encoded one after another with
+// nothing in between, and returns their encodings.
+func ReadAll(r io.Reader) ([][]byte, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%Size != 0 {
+		return nil, ErrSize
+	}
+	var res [][]byte
+	for len(data) > 0 {
+		res = append(res, data[:Size:Size])
+		data = data[Size:]
+	}
+	return res, nil
+}
+
+// FirstInvalid returns the index of the first header that is not a valid
+// transition from the one before it, or -1 if the chain is valid.
+func FirstInvalid(headers [][]byte) int {
+	for i := range headers {
+		var prev []byte
+		if i > 0 {
+			prev = headers[i-1]
+		}
+		if !ValidTransition(prev, headers[i], nil) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,212 @@
+package header
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/yangl1996/super-light-client/game"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// the first two headers of the Bitcoin main chain
+const genesisHex = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+const block1Hex = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+
+func TestMainChain(t *testing.T) {
+	genesis := decodeHex(t, genesisHex)
+	block1 := decodeHex(t, block1Hex)
+	h, err := Decode(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(h.Encode(), genesis) {
+		t.Error("header changes in a round trip")
+	}
+	hash := h.Hash()
+	if hex.EncodeToString(hash[:]) != "6fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000" {
+		t.Errorf("wrong hash of the genesis header %x", hash)
+	}
+	if Weight(genesis) != 1 || Work(h.Bits).Uint64() != 0x100010001 {
+		t.Errorf("wrong work %v of the genesis header", Work(h.Bits))
+	}
+	if !ValidTransition(nil, genesis, nil) || !ValidTransition(genesis, block1, nil) {
+		t.Error("valid chain is rejected")
+	}
+	if ValidTransition(block1, genesis, nil) {
+		t.Error("headers in the wrong order are accepted")
+	}
+	block1[76] ^= 1
+	if ValidTransition(genesis, block1, nil) {
+		t.Error("header that misses its target is accepted")
+	}
+}
+
+func TestTarget(t *testing.T) {
+	for _, bits := range []uint32{0, 0x01003456, 0x04923456, 0x23000001, 0x21010000} {
+		if Target(bits) != nil {
+			t.Errorf("invalid bits %x are accepted", bits)
+		}
+	}
+	if Target(0x01123456).Int64() != 0x12 || Target(0x05009234).Int64() != 0x92340000 {
+		t.Error("wrong target")
+	}
+}
+
+// regtestBits is the target of the Bitcoin regression test network, which about
+// every other hash meets.
+const regtestBits = 0x207fffff
+
+// mine returns a chain of n headers after prev with the given bits, which are
+// mined if mined is set. Seed makes different chains.
+func mine(prev []byte, n int, bits uint32, mined bool, seed byte) [][]byte {
+	var res [][]byte
+	for i := 0; i < n; i++ {
+		h := Header{Version: 1, Timestamp: uint32(i), Bits: bits}
+		h.MerkleRoot[0] = seed
+		if prev != nil {
+			p, _ := Decode(prev)
+			h.PrevBlock = p.Hash()
+		}
+		for mined && !h.MeetsTarget() {
+			h.Nonce++
+		}
+		prev = h.Encode()
+		res = append(res, prev)
+	}
+	return res
+}
+
+func TestFirstInvalid(t *testing.T) {
+	chain := mine(nil, 50, regtestBits, true, 0)
+	if i := FirstInvalid(chain); i != -1 {
+		t.Fatalf("mined chain is invalid at %v", i)
+	}
+	chain[30] = mine(chain[28], 1, regtestBits, true, 1)[0]
+	if i := FirstInvalid(chain); i != 30 {
+		t.Errorf("chain with a broken link is invalid at %v instead of 30", i)
+	}
+}
+
+const dim = 3
+
+func newTree(chain [][]byte) game.MerkleTree {
+	data := func(i int) []byte {
+		return chain[i]
+	}
+	return game.NewKVMerkleTreeWithHasher(game.NewInMemoryMerkleTreeStorage(), data, len(chain), dim, game.NewWeightedHasher(dim, Weight), false)
+}
+
+// play runs a verifier over header chains, and returns the index of the winner
+// and the reasons of the matches.
+func play(chains ...[][]byte) (int, []game.Reason) {
+	var trees []game.MerkleTree
+	for _, c := range chains {
+		trees = append(trees, newTree(c))
+	}
+	return playTrees(trees...)
+}
+
+// playTrees is play over the trees of header chains.
+func playTrees(trees ...game.MerkleTree) (int, []game.Reason) {
+	v := &game.Verifier{
+		Dim:             dim,
+		MerkleHasher:    game.NewWeightedHasher(dim, Weight),
+		ValidTransition: ValidTransition,
+	}
+	wg := &sync.WaitGroup{}
+	var toSessions []chan game.Message
+	for _, tree := range trees {
+		i := make(chan game.Message, 100)
+		o := make(chan game.Message, 100)
+		s := &game.Session{Tree: tree, I: i, O: o}
+		wg.Add(1)
+		go func() {
+			s.Run()
+			wg.Done()
+		}()
+		v.To = append(v.To, i)
+		v.From = append(v.From, o)
+		toSessions = append(toSessions, i)
+	}
+	_, winner, stats := v.Run()
+	for _, ch := range toSessions {
+		close(ch)
+	}
+	wg.Wait()
+	var reasons []game.Reason
+	for _, s := range stats {
+		reasons = append(reasons, s.Reason)
+	}
+	return winner, reasons
+}
+
+func TestGame(t *testing.T) {
+	honest := mine(nil, 40, regtestBits, true, 0)
+
+	// a shorter fork whose first header does not point to the one before it
+	broken := append(append([][]byte{}, honest[:20]...), mine(honest[18], 10, regtestBits, true, 1)...)
+	if winner, reasons := play(honest, broken); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonInvalidTransition {
+		t.Errorf("chain with a broken link is not rejected: winner %v, %v", winner, reasons)
+	}
+
+	// of two mined forks, the heavier one is the chain, whichever challenges
+	fork := append(append([][]byte{}, honest[:20]...), mine(honest[19], 30, regtestBits, true, 1)...)
+	for _, chains := range [][][][]byte{{honest, fork}, {fork, honest}} {
+		winner, reasons := play(chains...)
+		if winner < 0 || len(chains[winner]) != len(fork) || len(reasons) != 1 || reasons[0] != game.ReasonValidForks {
+			t.Errorf("heavier fork does not win: winner %v, %v", winner, reasons)
+		}
+	}
+
+	// a heavier fork that claims work it has not done; it challenges the chain, and
+	// fails to open a valid transition of its own at the fork
+	unmined := append(append([][]byte{}, honest[:20]...), mine(honest[19], 5, 0x1c00ffff, false, 1)...)
+	if winner, reasons := play(unmined, honest); winner != 1 || len(reasons) != 1 || reasons[0] != game.ReasonInvalidTransition {
+		t.Errorf("chain without work is not rejected: winner %v, %v", winner, reasons)
+	}
+
+	// a fork whose header at 3 links to the one at 1, and that opens the header at
+	// 1 as the one before it with its genuine proof
+	skipping := append(append([][]byte{}, honest[:3]...), mine(honest[1], 1, regtestBits, true, 1)...)
+	if winner, reasons := playTrees(newTree(honest), misplaced{newTree(skipping), 2, 1}); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonPrevLeafProof {
+		t.Errorf("chain that opens a misplaced header is not rejected: winner %v, %v", winner, reasons)
+	}
+	// or that opens a valid successor of the header at 2 in place of its own one
+	if winner, reasons := playTrees(newTree(honest), misplaced{newTree(append(skipping, honest[3])), 3, 4}); winner != 0 || len(reasons) != 1 || reasons[0] != game.ReasonLeafMismatch {
+		t.Errorf("chain that opens a misplaced disputed header is not rejected: winner %v, %v", winner, reasons)
+	}
+
+	// an honest prefix is nested in the chain
+	if winner, reasons := play(honest[:25], honest); winner != 1 || len(reasons) != 1 || reasons[0] != game.ReasonNestedLedger {
+		t.Errorf("prefix of the chain is not nested: winner %v, %v", winner, reasons)
+	}
+}
+
+// misplaced is a tree that opens the leaf at from in place of the one at idx.
+type misplaced struct {
+	game.MerkleTree
+	idx, from int
+}
+
+func (m misplaced) GetData(idx int) []byte {
+	if idx == m.idx {
+		idx = m.from
+	}
+	return m.MerkleTree.GetData(idx)
+}
+
+func (m misplaced) GetProof(idx int) []game.Hash {
+	if idx == m.idx {
+		idx = m.from
+	}
+	return m.MerkleTree.GetProof(idx)
+}
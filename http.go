@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// jsonHash is a game.Hash that is encoded as a hex string in JSON.
+type jsonHash game.Hash
+
+func (h jsonHash) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h[:])), nil
+}
+
+func (h *jsonHash) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	if len(b) != len(h) {
+		return errors.New("incorrect hash length")
+	}
+	copy(h[:], b)
+	return nil
+}
+
+func toJSONHashes(hs []game.Hash) []jsonHash {
+	res := make([]jsonHash, len(hs))
+	for i, h := range hs {
+		res[i] = jsonHash(h)
+	}
+	return res
+}
+
+type jsonMountainRange struct {
+	Roots []jsonHash `json:"roots"`
+	Sizes []int      `json:"sizes"`
+}
+
+type jsonLeaf struct {
+	Index int        `json:"index"`
+	Hash  jsonHash   `json:"hash"`
+	Data  []byte     `json:"data"`
+	Proof []jsonHash `json:"proof"`
+}
+
+type jsonTransition struct {
+	From      []byte     `json:"from"`
+	FromProof []jsonHash `json:"fromProof"`
+	To        []byte     `json:"to"`
+	Witness   []byte     `json:"witness,omitempty"`
+}
+
+type jsonOpenRequest struct {
+	Node  jsonHash `json:"node"`
+	Level int      `json:"level"`
+	Pos   int      `json:"pos"`
+	Depth int      `json:"depth,omitempty"`
+}
+
+type jsonOpenResponse struct {
+	Leaf       bool            `json:"leaf"`
+	Children   []jsonHash      `json:"children,omitempty"`
+	Transition *jsonTransition `json:"transition,omitempty"`
+}
+
+// httpGateway exposes the ledgers of a server over HTTP/JSON for light clients that
+// do not speak gob. Requests name the ledger in the ledger query parameter, and
+// get the default ledger without it.
+type httpGateway struct {
+	ledgers ledgers
+}
+
+func newHTTPGateway(hosted ledgers) http.Handler {
+	g := &httpGateway{hosted}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mountain-range", g.handleMountainRange)
+	mux.HandleFunc("/leaf/", g.handleLeaf)
+	mux.HandleFunc("/children", g.handleOpen)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("error encoding json:", err)
+	}
+}
+
+// tree returns the tree of the ledger that the request names, or writes an error
+// and returns false if the server does not host it.
+func (g *httpGateway) tree(w http.ResponseWriter, r *http.Request) (game.MerkleTree, bool) {
+	t, ok := g.ledgers[r.URL.Query().Get("ledger")]
+	if !ok {
+		http.Error(w, "unknown ledger", http.StatusNotFound)
+	}
+	return t, ok
+}
+
+func (g *httpGateway) handleMountainRange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tree, ok := g.tree(w, r)
+	if !ok {
+		return
+	}
+	mr := game.NewMountainRange(tree)
+	writeJSON(w, jsonMountainRange{toJSONHashes(mr.Roots), mr.Sizes})
+}
+
+func (g *httpGateway) handleLeaf(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	idx, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/leaf/"))
+	if err != nil {
+		http.Error(w, "invalid leaf index", http.StatusBadRequest)
+		return
+	}
+	tree, ok := g.tree(w, r)
+	if !ok {
+		return
+	}
+	n := 0
+	for _, s := range game.NewMountainRange(tree).Sizes {
+		n += s
+	}
+	if idx < 0 || idx >= n {
+		http.Error(w, "leaf index out of range", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, jsonLeaf{
+		Index: idx,
+		Hash:  jsonHash(tree.GetLeaf(idx)),
+		Data:  tree.GetData(idx),
+		Proof: toJSONHashes(tree.GetProof(idx)),
+	})
+}
+
+// handleOpen answers one step of the bisection game: the client posts the hash, the
+// level and the position of a node, and gets back its children, or its descendants
+// depth levels below if depth is set, or the state transition if it is a leaf. It
+// rejects depths above game.MaxDepth.
+func (g *httpGateway) handleOpen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req jsonOpenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	tree, ok := g.tree(w, r)
+	if !ok {
+		return
+	}
+	if req.Depth > game.MaxDepth(tree.GetDegree()) {
+		http.Error(w, "depth too large", http.StatusBadRequest)
+		return
+	}
+	node := game.Hash(req.Node)
+	if !game.HasNode(tree, node, req.Level, req.Pos) {
+		http.Error(w, "unknown node", http.StatusNotFound)
+		return
+	}
+	// the answer only depends on the node, so it can be cached forever
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	switch m := game.Open(tree, req.Level, req.Pos, req.Depth).(type) {
+	case game.NextChildren:
+		writeJSON(w, jsonOpenResponse{Children: toJSONHashes(m.Hashes)})
+	case game.StateTransition:
+		writeJSON(w, jsonOpenResponse{
+			Leaf: true,
+			Transition: &jsonTransition{
+				From:      m.From,
+				FromProof: toJSONHashes(m.FromProof),
+				To:        m.To,
+				Witness:   m.Witness,
+			},
+		})
+	}
+}
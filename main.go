@@ -5,23 +5,12 @@ import (
 	"os"
 	"math/rand"
 	"time"
-	"encoding/gob"
-	"github.com/yangl1996/super-light-client/game"
 )
 
 func main() {
-	gob.Register(game.OpenNext{})
-	gob.Register(game.StartRoot{})
-	gob.Register(game.NextChildren{})
-	gob.Register(game.StateTransition{})
-	gob.Register(game.MountainRange{})
-	gob.Register(game.GetMountainRange{})
-	gob.Register(game.NestedLedger{})
-	gob.Register(game.Terminate{})
-
 	rand.Seed(time.Now().UnixNano())
 	if len(os.Args) < 2 {
-		fmt.Println("subcommands: verify, serve, build")
+		fmt.Println("subcommands: verify, serve, build, truncate, bench, replay, checkfraud, reputation")
 		os.Exit(1)
 	}
 	switch os.Args[1] {
@@ -31,6 +20,16 @@ func main() {
 		serve(os.Args[2:])
 	case "build":
 		buildTree(os.Args[2:])
+	case "truncate":
+		truncateTree(os.Args[2:])
+	case "bench":
+		bench(os.Args[2:])
+	case "replay":
+		replay(os.Args[2:])
+	case "checkfraud":
+		checkFraud(os.Args[2:])
+	case "reputation":
+		reputation(os.Args[2:])
 	default:
 		fmt.Println("unknown subcommand")
 		os.Exit(1)
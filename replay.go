@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+func partyName(idx int) string {
+	switch idx {
+	case game.VerifierIndex:
+		return "verifier"
+	case game.BothWin:
+		return "both"
+	default:
+		return fmt.Sprintf("server %v", idx)
+	}
+}
+
+func replay(args []string) {
+	cmd := flag.NewFlagSet("replay", flag.ExitOnError)
+	deg := cmd.Int("dim", 50, "dimension of the tree")
+	verbose := cmd.Bool("v", false, "print every message in the transcript")
+	ledger := cmd.String("ledger", "test", "ledger in the trees: test, weighted for the test ledger with weights, headers for block headers, or accounts for transfers between accounts")
+	cmd.Parse(args)
+	if cmd.NArg() != 1 {
+		log.Fatalln("supply the transcript file as the command line argument")
+	}
+
+	f, err := os.Open(cmd.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	t, err := game.ReadTranscript(f)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	v := &game.Verifier{
+		Dim: *deg,
+		MerkleHasher: newHasher(*deg, *ledger),
+		ValidTransition: validTransition(*ledger),
+	}
+	for i, r := range t.Matches {
+		winner, reason := v.Replay(r)
+		fmt.Printf("match %v at %v: challenger %v, prover %v, winner %v: %v\n", i, r.Start.Format("15:04:05.000"), r.Challenger, r.Prover, partyName(winner), reason)
+		if winner != r.Winner || reason != r.Reason {
+			fmt.Printf("  recorded winner %v: %v\n", partyName(r.Winner), r.Reason)
+		}
+		if *verbose {
+			for _, e := range r.Entries {
+				fmt.Printf("  %v %v -> %v: %T\n", e.Time.Format("15:04:05.000000"), partyName(e.From), partyName(e.To), e.Message)
+			}
+		}
+	}
+}
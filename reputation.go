@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"github.com/yangl1996/super-light-client/game"
+)
+
+// peerRecord is what we have learned about a server from past verifications.
+type peerRecord struct {
+	Wins     int
+	Losses   int
+	Timeouts int
+	Frauds   int
+	LastSeen time.Time
+}
+
+// blacklisted tells if the server has been caught lying.
+func (p *peerRecord) blacklisted() bool {
+	return p.Frauds > 0
+}
+
+// reputationStore keeps the records of the servers in a local JSON file.
+type reputationStore struct {
+	path  string
+	Peers map[string]*peerRecord
+	lock  sync.Mutex
+}
+
+func loadReputation(path string) *reputationStore {
+	r := &reputationStore{
+		path:  path,
+		Peers: make(map[string]*peerRecord),
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return r
+	} else if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&r.Peers); err != nil {
+		log.Fatalln("error decoding reputation store:", err)
+	}
+	return r
+}
+
+func (r *reputationStore) save() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f, err := os.Create(r.path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.Peers); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func (r *reputationStore) peer(addr string) *peerRecord {
+	p, ok := r.Peers[addr]
+	if !ok {
+		p = &peerRecord{}
+		r.Peers[addr] = p
+	}
+	return p
+}
+
+func (r *reputationStore) recordResult(servers []string, res game.MatchResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	var winner, loser int
+	switch {
+	case res.Reason == game.ReasonUnsupportedLedger:
+		// the server is not at fault for not hosting the ledger we asked for
+		return
+	case res.Winner == game.BothWin:
+		for _, i := range []int{res.Challenger, res.Prover} {
+			p := r.peer(servers[i])
+			p.Wins += 1
+			p.LastSeen = now
+		}
+		return
+	case res.Winner == res.Challenger:
+		winner, loser = res.Challenger, res.Prover
+	default:
+		winner, loser = res.Prover, res.Challenger
+	}
+	if winner != game.VerifierIndex {
+		p := r.peer(servers[winner])
+		p.Wins += 1
+		p.LastSeen = now
+	}
+	p := r.peer(servers[loser])
+	if res.Reason == game.ReasonTimeout {
+		p.Timeouts += 1
+	} else {
+		p.Losses += 1
+		p.LastSeen = now
+	}
+	// we only take the word of our own matches for fraud; fraud proofs from
+	// elsewhere do not show which server committed to what they open
+	if res.Reason.IsFraud() {
+		p.Frauds += 1
+	}
+}
+
+// filter drops the blacklisted servers, and orders the rest so that the servers
+// with fewer timeouts and losses come first. Servers with at least maxTimeouts
+// timeouts are dropped too, unless that leaves less than two servers.
+func (r *reputationStore) filter(servers []string, maxTimeouts int) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var res, slow []string
+	for _, s := range servers {
+		p, ok := r.Peers[s]
+		if !ok {
+			res = append(res, s)
+		} else if p.blacklisted() {
+			log.Printf("skipping server %v which was caught lying\n", s)
+		} else if maxTimeouts > 0 && p.Timeouts >= maxTimeouts {
+			slow = append(slow, s)
+		} else {
+			res = append(res, s)
+		}
+	}
+	penalty := func(s string) int {
+		if p, ok := r.Peers[s]; ok {
+			return p.Timeouts + p.Losses
+		}
+		return 0
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return penalty(res[i]) < penalty(res[j])
+	})
+	sort.SliceStable(slow, func(i, j int) bool {
+		return penalty(slow[i]) < penalty(slow[j])
+	})
+	for len(res) < 2 && len(slow) > 0 {
+		res = append(res, slow[0])
+		slow = slow[1:]
+	}
+	for _, s := range slow {
+		log.Printf("skipping server %v which timed out too often\n", s)
+	}
+	return res
+}
+
+func reputation(args []string) {
+	cmd := flag.NewFlagSet("reputation", flag.ExitOnError)
+	path := cmd.String("db", "reputation.json", "path to the reputation store")
+	cmd.Parse(args)
+	if cmd.NArg() < 1 {
+		log.Fatalln("supply an action: list, or reset [servers]")
+	}
+	r := loadReputation(*path)
+
+	switch cmd.Arg(0) {
+	case "list":
+		var addrs []string
+		for s := range r.Peers {
+			addrs = append(addrs, s)
+		}
+		sort.Strings(addrs)
+		fmt.Printf("%-24s %8s %8s %8s %8s  %s\n", "server", "wins", "losses", "timeouts", "frauds", "last seen")
+		for _, s := range addrs {
+			p := r.Peers[s]
+			status := ""
+			if p.blacklisted() {
+				status = " (blacklisted)"
+			}
+			fmt.Printf("%-24s %8d %8d %8d %8d  %s%s\n", s, p.Wins, p.Losses, p.Timeouts, p.Frauds, p.LastSeen.Format(time.RFC3339), status)
+		}
+	case "reset":
+		if cmd.NArg() == 1 {
+			r.Peers = make(map[string]*peerRecord)
+		} else {
+			for _, s := range cmd.Args()[1:] {
+				delete(r.Peers, s)
+			}
+		}
+		r.save()
+	default:
+		log.Fatalln("unknown action")
+	}
+}
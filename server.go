@@ -4,18 +4,114 @@ import (
 	"flag"
 	"log"
 	"net"
+	"net/http"
 	"encoding/gob"
+	"encoding/json"
+	"os"
 	"github.com/yangl1996/super-light-client/game"
 )
 
+// ledgers are the trees that a server hosts by name. The default ledger, which
+// clients get unless they name another, is also under the empty name.
+type ledgers map[string]game.MerkleTree
+
+// ledgerConfig is a ledger in the config file of serve, which lists the ledgers
+// as a JSON array of them. The first one is the default ledger.
+type ledgerConfig struct {
+	Name    string `json:"name"`
+	DB      string `json:"db"`
+	Storage string `json:"storage,omitempty"` // pogreb if empty
+	Ledger  string `json:"ledger,omitempty"`  // test if empty
+}
+
+func readConfig(path string) []ledgerConfig {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	var config []ledgerConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		log.Fatalln("error reading config:", err)
+	}
+	if len(config) == 0 {
+		log.Fatalln("config lists no ledgers")
+	}
+	names := make(map[string]bool)
+	for i := range config {
+		if config[i].Name == "" || names[config[i].Name] {
+			log.Fatalf("ledger %v in config has an empty or duplicate name\n", i)
+		}
+		names[config[i].Name] = true
+		if config[i].Storage == "" {
+			config[i].Storage = "pogreb"
+		}
+		if config[i].Ledger == "" {
+			config[i].Ledger = "test"
+		}
+	}
+	return config
+}
+
+// openLedger opens the tree of a ledger to serve it, with a cache of cacheSize
+// bytes unless it is 0. It returns the cache, if any, to report its stats.
+func openLedger(c ledgerConfig, cacheSize int, inmem bool) (game.MerkleTree, *game.CachedMerkleTreeStorage) {
+	var db game.DiskBackedMerkleTreeStorage = openStorage(c.Storage, c.DB)
+	var cache *game.CachedMerkleTreeStorage
+	if cacheSize > 0 {
+		cache = game.NewCachedMerkleTreeStorage(db, cacheSize)
+		db = cache
+	}
+	tree := game.OpenKVMerkleTreeWithHasher(db, newHasher(db.GetDegree(), c.Ledger))
+	if inmem {
+		log.Println("loading the tree into memory")
+		tree = tree.CopyTo(game.NewInMemoryMerkleTreeStorage())
+		db.Close()
+	}
+	return witnessTree(tree, c.Ledger), cache
+}
+
 func serve(args []string) {
 	cmd := flag.NewFlagSet("serve", flag.ExitOnError)
 	port := cmd.String("addr", ":9000", "addr to listen for incoming connections")
-	dbPath := cmd.String("db", "tree.pogreb", "path to the database file")
+	dbPath := cmd.String("db", "tree.pogreb", "path to the database file, or directory for the flat storage")
+	backend := cmd.String("storage", "pogreb", "storage backend, pogreb or flat")
+	httpAddr := cmd.String("http", "", "addr to serve the HTTP/JSON gateway, disabled if empty")
+	grpcAddr := cmd.String("grpc", "", "addr to serve the grpc service, disabled if empty")
+	cacheSize := cmd.Int("cache", 0, "bytes of memory to cache each tree in, disabled if 0")
+	inmem := cmd.Bool("inmem", false, "load the whole trees into memory at startup")
+	ledger := cmd.String("ledger", "test", "ledger in the trees: test, weighted for the test ledger with weights, headers for block headers, or accounts for transfers between accounts")
+	configPath := cmd.String("config", "", "JSON file that lists the ledgers to serve by name, each with its db, storage and ledger, instead of -db, -storage and -ledger")
 	cmd.Parse(args)
 
-	db := game.NewPogrebMerkleTreeStorage(*dbPath)
-	tree := game.OpenKVMerkleTree(db)
+	config := []ledgerConfig{{DB: *dbPath, Storage: *backend, Ledger: *ledger}}
+	if *configPath != "" {
+		config = readConfig(*configPath)
+	}
+	hosted := make(ledgers)
+	caches := make(map[string]*game.CachedMerkleTreeStorage)
+	for i, c := range config {
+		if c.Name != "" {
+			log.Printf("opening ledger %v at %v\n", c.Name, c.DB)
+		}
+		tree, cache := openLedger(c, *cacheSize, *inmem)
+		hosted[c.Name] = tree
+		if i == 0 {
+			hosted[""] = tree
+		}
+		if cache != nil {
+			caches[c.Name] = cache
+		}
+	}
+
+	if *httpAddr != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(*httpAddr, newHTTPGateway(hosted)))
+		}()
+	}
+	if *grpcAddr != "" {
+		go serveGRPC(*grpcAddr, hosted)
+	}
 
 	l, err := net.Listen("tcp", *port)
 	if err != nil {
@@ -28,17 +124,28 @@ func serve(args []string) {
 			log.Fatal(err)
 		}
 		log.Println("light client connected")
-		go handleConn(conn, tree)
+		go func() {
+			handleConn(conn, hosted)
+			for name, cache := range caches {
+				st := cache.Stats()
+				if name == "" {
+					log.Printf("cache: %d hits, %d misses, %d bytes\n", st.Hits, st.Misses, st.Bytes)
+				} else {
+					log.Printf("cache of ledger %v: %d hits, %d misses, %d bytes\n", name, st.Hits, st.Misses, st.Bytes)
+				}
+			}
+		}()
 	}
 }
 
-func handleConn(conn net.Conn, tree game.MerkleTree) error {
+func handleConn(conn net.Conn, hosted ledgers) error {
 	toPeer := make(chan game.Message, 100)
 	fromPeer := make(chan game.Message, 100)
 	go writePeer(conn, toPeer)
 	go readPeer(conn, fromPeer)
 	s := &game.Session{
-		Tree: tree,
+		Tree: hosted[""],
+		Ledgers: hosted,
 		I: fromPeer,
 		O: toPeer,
 	}
@@ -57,7 +164,6 @@ func readPeer(conn net.Conn, ch chan<- game.Message) error {
 		}
 		ch <- d
 	}
-	return nil
 }
 
 func writePeer(conn net.Conn, ch <-chan game.Message) error {
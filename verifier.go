@@ -6,23 +6,49 @@ import (
 	"github.com/yangl1996/super-light-client/game"
 	"math"
 	"net"
+	"os"
 	"time"
 	"sync"
 )
 
-func newVerifier(servers []string, deg int) *game.Verifier {
+// unreachable returns the channels of a server that we cannot connect to, which
+// look to the verifier as if the server never answers.
+func unreachable() (chan<- game.Message, <-chan game.Message) {
+	t := make(chan game.Message, 100)
+	f := make(chan game.Message)
+	go func() {
+		for range t {
+		}
+	}()
+	close(f)
+	return t, f
+}
+
+func dialTCP(addr string) (chan<- game.Message, <-chan game.Message) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		log.Println(err)
+		return unreachable()
+	}
+	t := make(chan game.Message, 100)
+	f := make(chan game.Message, 100)
+	go readPeer(conn, f)
+	go writePeer(conn, t)
+	return t, f
+}
+
+func newVerifier(servers []string, deg int, ledger string, useGRPC bool, stateless bool, parallel bool) *game.Verifier {
 	var toProvers []chan<- game.Message
 	var fromProvers []<-chan game.Message
 
 	for _, addr := range servers {
-		conn, err := net.Dial("tcp", addr)
-		if err != nil {
-			log.Fatal(err)
+		var t chan<- game.Message
+		var f <-chan game.Message
+		if useGRPC {
+			t, f = dialGRPC(addr)
+		} else {
+			t, f = dialTCP(addr)
 		}
-		t := make(chan game.Message, 100)
-		f := make(chan game.Message, 100)
-		go readPeer(conn, f)
-		go writePeer(conn, t)
 		toProvers = append(toProvers, t)
 		fromProvers = append(fromProvers, f)
 	}
@@ -31,7 +57,10 @@ func newVerifier(servers []string, deg int) *game.Verifier {
 		To: toProvers,
 		From: fromProvers,
 		Dim: deg,
-		MerkleHasher: game.NewSHA256Hasher(deg),
+		MerkleHasher: newHasher(deg, ledger),
+		ValidTransition: validTransition(ledger),
+		Stateless: stateless,
+		Parallel: parallel,
 	}
 	return &v
 }
@@ -41,8 +70,25 @@ func verify(args []string) {
 	deg := cmd.Int("dim", 50, "dimension of the tree")
 	num := cmd.Int("N", 10, "number of back-to-back verifications per thread")
 	burst := cmd.Int("p", 1, "number of threads to generate verifications")
+	useGRPC := cmd.Bool("grpc", false, "talk to the servers over grpc instead of raw tcp")
+	stateless := cmd.Bool("stateless", false, "name the node to open in every request to the provers")
+	parallel := cmd.Bool("parallel", false, "run matches between disjoint pairs of servers in parallel")
+	transcriptPath := cmd.String("transcript", "", "file to record the messages of every match, disabled if empty")
+	fraudDir := cmd.String("fraud", "", "directory to save fraud proofs of lying servers, disabled if empty")
+	reputationPath := cmd.String("reputation", "", "path to the reputation store of the servers, disabled if empty")
+	timeout := cmd.Duration("timeout", 0, "time to wait for a server before it loses, 0 to wait forever")
+	maxTimeouts := cmd.Int("maxtimeouts", 3, "skip servers that timed out this many times in the reputation store, 0 to never skip")
+	diff := cmd.Bool("diff", false, "report where the ledgers of the servers in every match fork")
+	depth := cmd.Int("depth", 1, "number of tree levels the prover opens in every round of the game")
+	ledger := cmd.String("ledger", "test", "ledger in the trees: test, weighted for the test ledger with weights, headers for block headers, or accounts for transfers between accounts")
+	name := cmd.String("name", "", "name of the ledger to contest on servers that host several, their default ledger if empty")
 	cmd.Parse(args)
 	servers := cmd.Args()
+	var rep *reputationStore
+	if *reputationPath != "" {
+		rep = loadReputation(*reputationPath)
+		servers = rep.filter(servers, *maxTimeouts)
+	}
 	if len(servers) < 2 {
 		log.Fatalln("supply at least 2 servers as command line arguments")
 	}
@@ -63,22 +109,71 @@ func verify(args []string) {
 		}
 	}()
 
+	// totals of the statistics of the matches in all runs
+	statsLock := &sync.Mutex{}
+	var matches, rounds, bytesOut, bytesIn, hashes int
+	reasons := make(map[game.Reason]int)
+
+	var transcript *game.Transcript
+	if *transcriptPath != "" {
+		transcript = &game.Transcript{}
+	}
+
 	log.Printf("running verifications")
 	initWg := &sync.WaitGroup{}
 	for node := 0; node < *burst; node++ {
 		wg.Add(1)
 		initWg.Add(1)
 		go func() {
-			v := newVerifier(cmd.Args(), *deg)
+			v := newVerifier(servers, *deg, *ledger, *useGRPC, *stateless, *parallel)
+			v.Transcript = transcript
+			v.Ledger = *name
+			v.Timeout = *timeout
+			v.Depth = *depth
+			if *fraudDir != "" {
+				v.ReportFraud = fraudReporter(*fraudDir, servers, *deg, *ledger)
+			}
+			if *diff {
+				v.Diff = true
+				v.ReportDiff = func(r game.DiffReport) {
+					if r.FirstDiff == -1 {
+						log.Printf("server %v (%v) is a prefix of server %v (%v): %v of %v leaves in common\n", r.Prover, servers[r.Prover], r.Challenger, servers[r.Challenger], r.CommonPrefix, r.ChallengerSize)
+					} else {
+						log.Printf("servers %v (%v) and %v (%v) fork at leaf %v: %v and %v leaves after the fork\n", r.Challenger, servers[r.Challenger], r.Prover, servers[r.Prover], r.FirstDiff, r.ChallengerSize-r.CommonPrefix, r.ProverSize-r.CommonPrefix)
+					}
+				}
+			}
+			if rep != nil {
+				v.ReportResult = func(r game.MatchResult) {
+					rep.recordResult(servers, r)
+				}
+			}
 			initWg.Done()
 			initWg.Wait()
 			for i := 0; i < *num; i++ {
 				start := time.Now()
-				_, winner := v.Run()
+				mr, winner, stats := v.Run()
 				dur := float64(time.Since(start).Milliseconds())
 				resCh <- dur
+				statsLock.Lock()
+				for _, s := range stats {
+					matches += 1
+					rounds += s.Rounds
+					bytesOut += s.BytesToChallenger + s.BytesToProver
+					bytesIn += s.BytesFromChallenger + s.BytesFromProver
+					hashes += s.Hashes
+					reasons[s.Reason] += 1
+				}
+				statsLock.Unlock()
 				if *burst == 1 {
-					log.Printf("server %v is winner\n", winner)
+					if winner == game.NoWinner {
+						log.Printf("no server is winner\n")
+					} else {
+						log.Printf("server %v (%v) is winner\n", winner, servers[winner])
+						if _, ok := v.MerkleHasher.(*game.WeightedHasher); ok {
+							log.Printf("ledger of the winner weighs %v\n", mr.Weight())
+						}
+					}
 				}
 			}
 			wg.Done()
@@ -92,5 +187,30 @@ func verify(args []string) {
 	l.Unlock()
 
 	log.Printf("finished %v runs, avg %.2f ms, stddev %.2f ms\n", cnt, avg, stddev)
+	if cnt != 0 {
+		log.Printf("per run: %.2f matches, %.0f bytes sent, %.0f bytes received\n", float64(matches)/float64(cnt), float64(bytesOut)/float64(cnt), float64(bytesIn)/float64(cnt))
+	}
+	if matches != 0 {
+		log.Printf("per match: %.2f rounds, %.0f bytes sent, %.0f bytes received, %.1f hashes\n", float64(rounds)/float64(matches), float64(bytesOut)/float64(matches), float64(bytesIn)/float64(matches), float64(hashes)/float64(matches))
+		for r, n := range reasons {
+			log.Printf("%v matches ended with: %v\n", n, r)
+		}
+	}
+
+	if rep != nil {
+		rep.save()
+	}
+
+	if transcript != nil {
+		f, err := os.Create(*transcriptPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		if err := transcript.Write(f); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("recorded %v matches to %v\n", len(transcript.Matches), *transcriptPath)
+	}
 }
 